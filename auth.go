@@ -0,0 +1,312 @@
+package openapi
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// BasicVerifier verifies the basic credentials of a request for the named
+// security scheme, returning the scopes granted to them.
+// An error rejects the credentials.
+type BasicVerifier func(r *http.Request, scheme, username, password string) ([]string, error)
+
+// BearerVerifier verifies the bearer token of a request for the named
+// security scheme, returning the scopes granted to it. It verifies the
+// tokens of "bearer", "oauth2" and "openIdConnect" schemes.
+// An error rejects the token.
+type BearerVerifier func(r *http.Request, scheme, token string) ([]string, error)
+
+// APIKeyVerifier verifies the API key of a request for the named security
+// scheme, returning the scopes granted to it. The key is read from the
+// header, query parameter or cookie of the scheme.
+// An error rejects the key.
+type APIKeyVerifier func(r *http.Request, scheme, key string) ([]string, error)
+
+// AuthConfig configures the enforcement of the declared security.
+type AuthConfig struct {
+	// Spec is the configuration the spec is built with. Its security is
+	// required by all operations, unless they declare their own, and its
+	// registry resolves the operations of the handlers built with BuildHandlerIn.
+	Spec SpecConfig
+
+	// Basic verifies the credentials of "basic" schemes.
+	Basic BasicVerifier
+
+	// Bearer verifies the tokens of "bearer", "oauth2" and "openIdConnect" schemes.
+	Bearer BearerVerifier
+
+	// APIKey verifies the keys of "apiKey" schemes.
+	APIKey APIKeyVerifier
+
+	// Realm is the realm of the authentication challenges.
+	Realm string
+}
+
+// RequireAuth returns a middleware enforcing the security declared by the
+// operations of the given router, to be used on the router itself.
+//
+// A request is allowed if it satisfies all the requirements of any of the
+// security groups of its operation: its credentials are verified by the
+// verifier of each scheme and granted all the required scopes. Mutual TLS
+// is satisfied by a verified client certificate. Schemes without a verifier
+// are never satisfied.
+//
+// Requests without valid credentials are rejected with a 401 response
+// containing the authentication challenges of the "basic", "bearer" and
+// "apiKey" schemes, the latter being the non-standard "APIKey" challenge
+// naming the key. Mutual TLS has no challenge, so requests of routes only
+// secured by it are rejected without any. Requests with valid credentials
+// lacking scopes are rejected with a 403 response, containing an
+// "insufficient_scope" bearer challenge for bearer tokens. Requests of routes
+// without security requirements are passed through, requests not matching
+// any route require the default security of the spec configuration.
+func RequireAuth(r chi.Routes, cfg AuthConfig) func(http.Handler) http.Handler {
+	return RequireAuthFrom(ChiWalker(r), cfg)
+}
+
+// RequireAuthFrom returns a middleware like RequireAuth, enforcing the
+// security declared by the operations of the routes of the given walker.
+// The middleware wraps the router of the walker, or is used on the router
+// itself.
+func RequireAuthFrom(w MatchWalker, cfg AuthConfig) func(http.Handler) http.Handler {
+	a := &authenticator{
+		cfg: cfg,
+		ops: &routeOperations{walker: w, reg: registryOrDefault(cfg.Spec.Registry)},
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			groups := a.security(req)
+			if len(groups) == 0 {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			status, insufficient := a.authorize(req, groups)
+			var challenges []string
+			switch status {
+			case http.StatusOK:
+				next.ServeHTTP(rw, req)
+				return
+			case http.StatusUnauthorized:
+				challenges = a.challenges(groups)
+			case http.StatusForbidden:
+				challenges = a.scopeChallenges(insufficient)
+			}
+			for _, challenge := range challenges {
+				rw.Header().Add("WWW-Authenticate", challenge)
+			}
+			http.Error(rw, http.StatusText(status), status)
+		})
+	}
+}
+
+type authenticator struct {
+	cfg AuthConfig
+	ops *routeOperations
+}
+
+// security returns the security groups of the route matching the request.
+func (a *authenticator) security(req *http.Request) []securityGroup {
+	route, ok := a.ops.route(req)
+	if !ok {
+		// Unresolved routes require the default security.
+		return securityGroups(a.cfg.Spec.Security)
+	}
+
+	op, ok := a.ops.operations()[route]
+	switch {
+	case !ok:
+		// Undocumented routes require the default security.
+		return securityGroups(a.cfg.Spec.Security)
+	case op.public:
+		return nil
+	case len(op.security) > 0:
+		return op.security
+	default:
+		return securityGroups(a.cfg.Spec.Security)
+	}
+}
+
+// authorize returns the status of the request for the given security
+// groups, being 200 if any of the groups is satisfied, and the verified
+// requirements lacking scopes if it is 403.
+func (a *authenticator) authorize(req *http.Request, groups []securityGroup) (int, []SecurityRequirement) {
+	verified := map[string]verification{}
+
+	var insufficient []SecurityRequirement
+	status := http.StatusUnauthorized
+	for _, group := range groups {
+		groupStatus, reqs := a.authorizeGroup(req, group, verified)
+		switch groupStatus {
+		case http.StatusOK:
+			return http.StatusOK, nil
+		case http.StatusForbidden:
+			status = http.StatusForbidden
+			insufficient = append(insufficient, reqs...)
+		}
+	}
+	return status, insufficient
+}
+
+type verification struct {
+	scopes []string
+	ok     bool
+}
+
+func (a *authenticator) authorizeGroup(req *http.Request, group securityGroup, verified map[string]verification) (int, []SecurityRequirement) {
+	var insufficient []SecurityRequirement
+	for _, r := range group {
+		v, ok := verified[r.Name]
+		if !ok {
+			v.scopes, v.ok = a.verify(req, r)
+			verified[r.Name] = v
+		}
+
+		if !v.ok {
+			return http.StatusUnauthorized, nil
+		}
+		for _, scope := range r.Scopes {
+			if !slices.Contains(v.scopes, scope) {
+				insufficient = append(insufficient, r)
+				break
+			}
+		}
+	}
+	if len(insufficient) > 0 {
+		return http.StatusForbidden, insufficient
+	}
+	return http.StatusOK, nil
+}
+
+// verify verifies the credentials of the request for the given requirement,
+// returning the granted scopes.
+func (a *authenticator) verify(req *http.Request, r SecurityRequirement) ([]string, bool) {
+	var (
+		scopes []string
+		err    error
+	)
+	switch r.Security.Type {
+	case secTypeBasic:
+		user, pass, ok := req.BasicAuth()
+		if !ok || a.cfg.Basic == nil {
+			return nil, false
+		}
+		scopes, err = a.cfg.Basic(req, r.Name, user, pass)
+	case secTypeBearer, secTypeOAuth2, secTypeOpenIDConnect:
+		token, ok := bearerToken(req)
+		if !ok || a.cfg.Bearer == nil {
+			return nil, false
+		}
+		scopes, err = a.cfg.Bearer(req, r.Name, token)
+	case secTypeAPIKey:
+		key := apiKey(req, r.Security)
+		if key == "" || a.cfg.APIKey == nil {
+			return nil, false
+		}
+		scopes, err = a.cfg.APIKey(req, r.Name, key)
+	case secTypeMutualTLS:
+		return nil, req.TLS != nil && len(req.TLS.VerifiedChains) > 0
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+	return scopes, true
+}
+
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func apiKey(req *http.Request, sec Security) string {
+	switch sec.APIKeyIn {
+	case "header":
+		return req.Header.Get(sec.APIKeyName)
+	case "query":
+		return req.URL.Query().Get(sec.APIKeyName)
+	case "cookie":
+		cookie, err := req.Cookie(sec.APIKeyName)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	default:
+		return ""
+	}
+}
+
+// challenges returns the authentication challenges of the given security groups.
+func (a *authenticator) challenges(groups []securityGroup) []string {
+	var challenges []string
+	for _, group := range groups {
+		for _, r := range group {
+			var challenge string
+			switch r.Security.Type {
+			case secTypeBasic:
+				challenge = a.challenge("Basic")
+			case secTypeBearer, secTypeOAuth2, secTypeOpenIDConnect:
+				challenge = a.challenge("Bearer")
+			case secTypeAPIKey:
+				challenge = a.challenge("APIKey", "name", r.Security.APIKeyName, "in", r.Security.APIKeyIn)
+			default:
+				continue
+			}
+
+			if !slices.Contains(challenges, challenge) {
+				challenges = append(challenges, challenge)
+			}
+		}
+	}
+	return challenges
+}
+
+// scopeChallenges returns the "insufficient_scope" challenges of the
+// bearer token requirements lacking scopes, as defined by RFC 6750.
+func (a *authenticator) scopeChallenges(insufficient []SecurityRequirement) []string {
+	var challenges []string
+	for _, r := range insufficient {
+		switch r.Security.Type {
+		case secTypeBearer, secTypeOAuth2, secTypeOpenIDConnect:
+		default:
+			continue
+		}
+
+		challenge := a.challenge("Bearer", "error", "insufficient_scope", "scope", strings.Join(r.Scopes, " "))
+		if !slices.Contains(challenges, challenge) {
+			challenges = append(challenges, challenge)
+		}
+	}
+	return challenges
+}
+
+// challenge returns the challenge of the authentication scheme with the
+// realm, if any, and the given parameters as name and value pairs.
+func (a *authenticator) challenge(scheme string, params ...string) string {
+	if a.cfg.Realm != "" {
+		params = append([]string{"realm", a.cfg.Realm}, params...)
+	}
+
+	attrs := make([]string, 0, len(params)/2)
+	for i := 0; i+1 < len(params); i += 2 {
+		attrs = append(attrs, params[i]+"="+quote(params[i+1]))
+	}
+	if len(attrs) == 0 {
+		return scheme
+	}
+	return scheme + " " + strings.Join(attrs, ", ")
+}
+
+var quoteReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// quote returns the value as a quoted string, as defined by RFC 9110.
+func quote(s string) string {
+	return `"` + quoteReplacer.Replace(s) + `"`
+}
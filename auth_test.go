@@ -0,0 +1,226 @@
+package openapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gamefabric/openapi"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAuth(t *testing.T) {
+	apiKey := openapi.Security{Type: "apiKey", APIKeyName: "X-API-Key", APIKeyIn: "header"}
+
+	mux := chi.NewMux()
+	mux.Use(openapi.RequireAuth(mux, openapi.AuthConfig{
+		Spec: openapi.SpecConfig{
+			Security: [][]openapi.SecurityRequirement{
+				{openapi.Requirement("bearer", openapi.SecurityBearer)},
+			},
+		},
+		Basic: func(_ *http.Request, _, username, password string) ([]string, error) {
+			if username != "user" || password != "pass" {
+				return nil, errors.New("invalid credentials")
+			}
+			return nil, nil
+		},
+		Bearer: func(_ *http.Request, _, token string) ([]string, error) {
+			scopes, ok := strings.CutPrefix(token, "token:")
+			if !ok {
+				return nil, errors.New("invalid token")
+			}
+			return strings.Split(scopes, ","), nil
+		},
+		APIKey: func(_ *http.Request, _, key string) ([]string, error) {
+			if key != "key" {
+				return nil, errors.New("invalid key")
+			}
+			return nil, nil
+		},
+		Realm: "test",
+	}))
+
+	handler := func(rw http.ResponseWriter, req *http.Request) {}
+	mux.With(openapi.Op().ID("default").Build()).Get("/default", handler)
+	mux.With(openapi.Op().ID("public").Public().Build()).Get("/public", handler)
+	mux.With(openapi.Op().ID("scoped").RequiresAuth("bearer", openapi.SecurityBearer, "write").Build()).Get("/scoped", handler)
+	mux.With(openapi.Op().ID("and").RequiresAllAuth(
+		openapi.Requirement("basic", openapi.SecurityBasic),
+		openapi.Requirement("apiKey", apiKey),
+	).Build()).Get("/and", handler)
+	mux.With(openapi.Op().ID("or").
+		RequiresAuth("basic", openapi.SecurityBasic).
+		RequiresAuth("apiKey", apiKey).
+		Build()).Get("/or", handler)
+	mux.With(openapi.Op().ID("apiKey").RequiresAuth("apiKey", apiKey).Build()).Get("/apikey", handler)
+	mux.With(openapi.Op().ID("mtls").RequiresAuth("mtls", openapi.SecurityMutualTLS).Build()).Get("/mtls", handler)
+	mux.With(openapi.Op().ID("optional").
+		RequiresAuth("basic", openapi.SecurityBasic).
+		AllowsAnonymous().
+		Build()).Get("/optional", handler)
+	mux.Get("/undocumented", handler)
+
+	tests := []struct {
+		name          string
+		path          string
+		header        http.Header
+		wantStatus    int
+		wantChallenge []string
+	}{
+		{
+			name:          "default security without credentials",
+			path:          "/default",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: []string{`Bearer realm="test"`},
+		},
+		{
+			name:       "default security with token",
+			path:       "/default",
+			header:     http.Header{"Authorization": {"Bearer token:read"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "public",
+			path:       "/public",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "missing scope",
+			path:          "/scoped",
+			header:        http.Header{"Authorization": {"Bearer token:read"}},
+			wantStatus:    http.StatusForbidden,
+			wantChallenge: []string{`Bearer realm="test", error="insufficient_scope", scope="write"`},
+		},
+		{
+			name:       "granted scope",
+			path:       "/scoped",
+			header:     http.Header{"Authorization": {"Bearer token:read,write"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "invalid token",
+			path:          "/scoped",
+			header:        http.Header{"Authorization": {"Bearer invalid"}},
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: []string{`Bearer realm="test"`},
+		},
+		{
+			name:          "and with one scheme",
+			path:          "/and",
+			header:        http.Header{"X-Api-Key": {"key"}},
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: []string{`Basic realm="test"`, `APIKey realm="test", name="X-API-Key", in="header"`},
+		},
+		{
+			name:       "and with all schemes",
+			path:       "/and",
+			header:     http.Header{"X-Api-Key": {"key"}, "Authorization": {"Basic dXNlcjpwYXNz"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "or with one scheme",
+			path:       "/or",
+			header:     http.Header{"X-Api-Key": {"key"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "or with invalid scheme",
+			path:          "/or",
+			header:        http.Header{"X-Api-Key": {"invalid"}},
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: []string{`Basic realm="test"`, `APIKey realm="test", name="X-API-Key", in="header"`},
+		},
+		{
+			name:          "api key without key",
+			path:          "/apikey",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: []string{`APIKey realm="test", name="X-API-Key", in="header"`},
+		},
+		{
+			name:       "mutual tls without certificate",
+			path:       "/mtls",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "anonymous",
+			path:       "/optional",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "undocumented",
+			path:          "/undocumented",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: []string{`Bearer realm="test"`},
+		},
+		{
+			name:          "not found without credentials",
+			path:          "/missing",
+			wantStatus:    http.StatusUnauthorized,
+			wantChallenge: []string{`Bearer realm="test"`},
+		},
+		{
+			name:       "not found with token",
+			path:       "/missing",
+			header:     http.Header{"Authorization": {"Bearer token:read"}},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			assert.Equal(t, test.wantChallenge, rec.Header().Values("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestRequireAuthSubrouter(t *testing.T) {
+	mux := chi.NewMux()
+	mux.Route("/api", func(r chi.Router) {
+		r.Use(openapi.RequireAuth(r, openapi.AuthConfig{
+			Bearer: func(_ *http.Request, _, token string) ([]string, error) {
+				if token != "token" {
+					return nil, errors.New("invalid token")
+				}
+				return nil, nil
+			},
+		}))
+		r.With(openapi.Op().ID("secret").RequiresAuth("bearer", openapi.SecurityBearer).Build()).
+			Get("/secret", func(rw http.ResponseWriter, req *http.Request) {})
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/secret", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, []string{"Bearer"}, rec.Header().Values("WWW-Authenticate"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/secret", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequireAuthQuotesRealm(t *testing.T) {
+	mux := chi.NewMux()
+	mux.Use(openapi.RequireAuth(mux, openapi.AuthConfig{Realm: `my "test" \ realm`}))
+	mux.With(openapi.Op().ID("basic").RequiresAuth("basic", openapi.SecurityBasic).Build()).Get("/basic", func(rw http.ResponseWriter, req *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/basic", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, []string{`Basic realm="my \"test\" \\ realm"`}, rec.Header().Values("WWW-Authenticate"))
+}
@@ -18,12 +18,28 @@ import (
 )
 
 const (
-	directiveGen      = "gen"
-	directiveReadonly = "readonly"
-	directiveRequired = "required"
-	directiveFormat   = "format"
+	directiveGen        = "gen"
+	directiveRequired   = "required"
+	directiveOptional   = "optional"
+	directiveReadonly   = "readonly"
+	directiveWriteonly  = "writeonly"
+	directiveNullable   = "nullable"
+	directiveDeprecated = "deprecated"
+	directiveSensitive  = "sensitive"
+	directiveFormat     = "format"
 )
 
+// attrDirectives are the directives setting a property attribute.
+var attrDirectives = []string{
+	directiveRequired,
+	directiveOptional,
+	directiveReadonly,
+	directiveWriteonly,
+	directiveNullable,
+	directiveDeprecated,
+	directiveSensitive,
+}
+
 // Generator is a struct documentation generator. It gathers struct
 // field doc blocks, making a `Docs` function that returns them as
 // a map[string]string, using the field name or tag name as the key.
@@ -76,7 +92,7 @@ type pkgInfo struct {
 type structInfo struct {
 	Name    string
 	Docs    map[string]string
-	Attrs   map[string]string
+	Attrs   map[string][]string
 	Formats map[string]string
 }
 
@@ -153,7 +169,7 @@ func (g *Generator) gatherStructInfo(name string, typ *ast.StructType) (structIn
 	info := structInfo{
 		Name:    name,
 		Docs:    map[string]string{},
-		Attrs:   map[string]string{},
+		Attrs:   map[string][]string{},
 		Formats: map[string]string{},
 	}
 	for _, field := range typ.Fields.List {
@@ -173,10 +189,10 @@ func (g *Generator) gatherStructInfo(name string, typ *ast.StructType) (structIn
 		ds := directives(field.Doc)
 		for _, d := range ds {
 			switch {
-			case d == directiveReadonly:
-				info.Attrs[fldName] = "readonly"
-			case d == directiveRequired && info.Attrs[fldName] == "":
-				info.Attrs[fldName] = "required"
+			case slices.Contains(attrDirectives, d):
+				if !slices.Contains(info.Attrs[fldName], d) {
+					info.Attrs[fldName] = append(info.Attrs[fldName], d)
+				}
 			case strings.HasPrefix(d, directiveFormat):
 				_, val, found := strings.Cut(d, "=")
 				if !found {
@@ -267,10 +283,10 @@ func ({{ .Name }}) Docs() map[string]string {
 {{ end }}
 {{- if .Attrs }}
 // Attributes returns a set of property attributes per property.
-func ({{ .Name }}) Attributes() map[string]string {
-  return map[string]string {
+func ({{ .Name }}) Attributes() map[string][]string {
+  return map[string][]string {
   {{- range $k, $v := .Attrs }}
-    "{{ $k }}": "{{ $v }}",
+    "{{ $k }}": { {{- range $i, $a := $v }}{{ if $i }}, {{ end }}"{{ $a }}"{{ end -}} },
   {{- end }}
   }
 }
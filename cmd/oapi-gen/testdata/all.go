@@ -11,9 +11,9 @@ func (TestObject) Docs() map[string]string {
 }
 
 // Attributes returns a set of property attributes per property.
-func (TestObject) Attributes() map[string]string {
-	return map[string]string{
-		"B": "required",
+func (TestObject) Attributes() map[string][]string {
+	return map[string][]string{
+		"B": {"required"},
 	}
 }
 
@@ -23,13 +23,17 @@ func (TestOtherObject) Docs() map[string]string {
 		"D": "D is another example field.",
 		"E": "E is a formatted example field.",
 		"c": "C is an example field.",
+		"f": "F is a field with multiple attributes.",
+		"g": "G is an optional field.",
 	}
 }
 
 // Attributes returns a set of property attributes per property.
-func (TestOtherObject) Attributes() map[string]string {
-	return map[string]string{
-		"D": "readonly",
+func (TestOtherObject) Attributes() map[string][]string {
+	return map[string][]string{
+		"D": {"readonly"},
+		"f": {"required", "writeonly", "sensitive"},
+		"g": {"optional"},
 	}
 }
 
@@ -11,8 +11,8 @@ func (TestObject) Docs() map[string]string {
 }
 
 // Attributes returns a set of property attributes per property.
-func (TestObject) Attributes() map[string]string {
-	return map[string]string{
-		"B": "required",
+func (TestObject) Attributes() map[string][]string {
+	return map[string][]string{
+		"B": {"required"},
 	}
 }
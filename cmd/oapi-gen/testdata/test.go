@@ -26,4 +26,16 @@ type TestOtherObject struct {
 	//
 	//openapi:format=ipv4 // This should be ignored
 	E string
+
+	// F is a field with multiple attributes.
+	//
+	//openapi:required
+	//openapi:writeonly
+	//openapi:sensitive
+	F string `json:"f,omitempty"`
+
+	// G is an optional field.
+	//
+	//openapi:optional
+	G string `json:"g"`
 }
@@ -1,3 +1,3 @@
 // Package openapi provides a framework for generating OpenAPI v3 specifications
-// using a chi mux.
+// using a chi mux or an http.ServeMux.
 package openapi
@@ -0,0 +1,138 @@
+package openapi
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	kin "github.com/getkin/kin-openapi/openapi3"
+)
+
+// propertyField is a struct field documented as a schema property.
+type propertyField struct {
+	reflect.StructField
+
+	// Property is the property name of the field.
+	Property string
+
+	// Path is the index sequence of the field from the root struct,
+	// including the indexes of the embedded structs it is promoted from.
+	Path []int
+
+	// OmitEmpty determines if the field is omitted when empty.
+	OmitEmpty bool
+
+	// OmitZero determines if the field is omitted when zero.
+	OmitZero bool
+}
+
+// propertyFields returns the fields of the given struct type keyed
+// by their property name, following the field naming of the schema generator.
+// Fields of embedded structs without a name are promoted.
+func propertyFields(t reflect.Type) map[string]propertyField {
+	fields := map[string]propertyField{}
+	appendPropertyFields(fields, nil, t)
+	return fields
+}
+
+func appendPropertyFields(fields map[string]propertyField, path []int, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		fPath := append(slices.Clone(path), i)
+
+		jsonTag, hasTag := f.Tag.Lookup("json")
+		if jsonTag == "-" {
+			continue
+		}
+		if f.Anonymous && jsonTag == "" {
+			appendPropertyFields(fields, fPath, f.Type)
+			continue
+		}
+		if !isExported(f.Name) || !hasTag {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		// Shallower fields hide the promoted fields of the same name.
+		if existing, ok := fields[name]; ok && len(existing.Path) <= len(fPath) {
+			continue
+		}
+		fields[name] = propertyField{
+			StructField: f,
+			Property:    name,
+			Path:        fPath,
+			OmitEmpty:   hasOption(opts, "omitempty"),
+			OmitZero:    hasOption(opts, "omitzero"),
+		}
+	}
+}
+
+// embeddedStruct is a struct embedded without a property name,
+// having its fields promoted to the embedding struct.
+type embeddedStruct struct {
+	Type reflect.Type
+
+	// Path is the index sequence of the embedded field from the root struct.
+	Path []int
+}
+
+// embeddedStructs returns the embedded structs of the given struct type,
+// with the most deeply embedded structs first.
+func embeddedStructs(t reflect.Type) []embeddedStruct {
+	return appendEmbeddedStructs(nil, nil, t)
+}
+
+func appendEmbeddedStructs(embedded []embeddedStruct, path []int, t reflect.Type) []embeddedStruct {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.Anonymous || f.Tag.Get("json") != "" {
+			continue
+		}
+
+		typ := f.Type
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			continue
+		}
+
+		fPath := append(slices.Clone(path), i)
+		embedded = appendEmbeddedStructs(embedded, fPath, typ)
+		embedded = append(embedded, embeddedStruct{Type: typ, Path: fPath})
+	}
+	return embedded
+}
+
+// promotedProperties returns the properties of the schema promoted
+// from the given embedded struct.
+func promotedProperties(schema *kin.Schema, fields map[string]propertyField, e embeddedStruct) kin.Schemas {
+	props := kin.Schemas{}
+	for name, prop := range schema.Properties {
+		field, ok := fields[name]
+		if !ok || len(field.Path) <= len(e.Path) || !slices.Equal(field.Path[:len(e.Path)], e.Path) {
+			continue
+		}
+		props[name] = prop
+	}
+	return props
+}
+
+func hasOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
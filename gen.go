@@ -1,9 +1,13 @@
 package openapi
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,68 +26,195 @@ type SpecConfig struct {
 	// ObjPkgSegments determines the maximum number of
 	// package segments to use to identify an object.
 	ObjPkgSegments int
+
+	// TypeOverrides sets the schema to use for the given types
+	// instead of the generated one. Overrides take precedence over
+	// the built-in overrides for well-known types.
+	TypeOverrides map[reflect.Type]*kin.Schema
+
+	// Implementations sets the implementations of interface types,
+	// used to document fields of those types as polymorphic schemas.
+	Implementations map[reflect.Type]Implementations
+
+	// ValidationTag is the struct tag containing validation rules,
+	// e.g. "validate". The supported rules are translated into schema
+	// constraints. Validation rules are ignored if empty.
+	ValidationTag string
+
+	// EmbeddedAllOf documents embedded structs as "allOf" their component
+	// schemas instead of promoting their properties.
+	EmbeddedAllOf bool
+
+	// Required determines how required properties are determined.
+	Required RequiredPolicy
+
+	// InputSchemas documents types with read only or write only properties
+	// as separate input and output schemas. The input schema, named with
+	// an "Input" suffix, omits read only properties and is used for request
+	// bodies. The output schema omits write only properties and is used
+	// for responses.
+	InputSchemas bool
+
+	// Registry is the registry of the handlers built with BuildHandlerIn.
+	// The default registry of BuildHandler is used if nil.
+	Registry *Registry
+
+	// Security sets the security required by all operations, unless they
+	// declare their own. Each group lists the requirements that are all
+	// required, any of the groups being sufficient. An empty group allows
+	// anonymous access.
+	Security [][]SecurityRequirement
 }
 
 // BuildSpec builds openapi v3 spec from the given chi router.
+//
+// Operations documented on several methods of the same route, such as routes
+// handling all methods, have their IDs suffixed with the method to keep them
+// unique, e.g. "health-get". Inspect and OperationFromContext report the
+// same suffixed IDs.
 func BuildSpec(r chi.Routes, cfg SpecConfig) (kin.T, error) {
-	gen := newGenerator()
-	gen.objPkgSegments = cfg.ObjPkgSegments
-
-	err := chi.Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
-		for _, prefix := range cfg.StripPrefixes {
-			if !strings.HasPrefix(route, prefix) {
-				continue
-			}
-			route = strings.TrimPrefix(route, prefix)
-		}
+	return BuildSpecFrom(ChiWalker(r), cfg)
+}
 
-		var (
-			op    Operation
-			found bool
-		)
-		for _, m := range middlewares {
-			h := m(opHandler{})
-			if oph, ok := h.(opHandler); ok {
-				op = op.Merge(oph.Op)
-				found = true
-			}
-		}
+// BuildSpecFrom builds openapi v3 spec from the routes of the given walker,
+// allowing specs to be built from routers other than chi.
+func BuildSpecFrom(w Walker, cfg SpecConfig) (kin.T, error) {
+	gen := newGenerator(cfg)
 
-		if o, ok := opReg.Op(handler); ok {
-			op = op.Merge(o)
-			found = true
+	if len(cfg.Security) > 0 {
+		secReqs, err := gen.addSecuritySchemes(securityGroups(cfg.Security))
+		if err != nil {
+			return kin.T{}, fmt.Errorf("generating document security requirement: %w", err)
 		}
+		gen.doc.Security = *secReqs
+	}
 
-		if !found || op.id == "" {
+	err := walkOperations(w, registryOrDefault(cfg.Registry), func(method, route string, op Operation, _ OperationSource) error {
+		if op.id == "" {
 			return nil
 		}
 
-		return gen.AddOperation(method, route, op)
+		return gen.AddOperation(method, normalizePath(route, cfg.StripPrefixes), op)
 	})
 	if err != nil {
 		return kin.T{}, err
 	}
+
+	if cfg.InputSchemas {
+		if err = gen.splitInputSchemas(); err != nil {
+			return kin.T{}, err
+		}
+	}
 	return gen.doc, nil
 }
 
+// walkOperations calls fn with the operation of each route of the router
+// that is documented, either by its middlewares or its handler.
+//
+// The IDs of the operations documented on several methods of the same
+// route are suffixed with the method, so that the spec, the inspected
+// routes and the operations at runtime agree on them.
+func walkOperations(w Walker, reg *Registry, fn func(method, route string, op Operation, src OperationSource) error) error {
+	var ops []routeOperation
+	err := w.Walk(func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		op, src := OperationOf(reg, handler, middlewares...)
+		if src == 0 {
+			return nil
+		}
+		ops = append(ops, routeOperation{method: method, route: route, op: op, src: src})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, o := range uniqueOperationIDs(ops) {
+		if err = fn(o.method, o.route, o.op, o.src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type routeOperation struct {
+	method string
+	route  string
+	op     Operation
+	src    OperationSource
+}
+
+// uniqueOperationIDs suffixes the IDs of the operations documented on
+// several methods of the same route with their method.
+func uniqueOperationIDs(ops []routeOperation) []routeOperation {
+	counts := map[[2]string]int{}
+	for _, o := range ops {
+		if o.op.id != "" {
+			counts[[2]string{o.route, o.op.id}]++
+		}
+	}
+
+	for i, o := range ops {
+		if counts[[2]string{o.route, o.op.id}] > 1 {
+			ops[i].op.id += "-" + strings.ToLower(o.method)
+		}
+	}
+	return ops
+}
+
+// normalizePath strips the given prefixes from the route.
+func normalizePath(route string, stripPrefixes []string) string {
+	for _, prefix := range stripPrefixes {
+		if !strings.HasPrefix(route, prefix) {
+			continue
+		}
+		route = strings.TrimPrefix(route, prefix)
+	}
+	return route
+}
+
 type generator struct {
 	doc kin.T
-	gen *kingen.Generator
 
-	objPkgSegments int
+	objPkgSegments  int
+	typeOverrides   map[reflect.Type]*kin.Schema
+	implementations map[reflect.Type]Implementations
+	validationTag   string
+	embeddedAllOf   bool
+	required        RequiredPolicy
+
+	// inputOnly are the component schemas only used as input,
+	// which are not split into input and output schemas.
+	inputOnly map[string]bool
 }
 
-func newGenerator() *generator {
+func newGenerator(cfg SpecConfig) *generator {
 	comp := kin.NewComponents()
 	comp.Schemas = kin.Schemas{}
 	comp.SecuritySchemes = kin.SecuritySchemes{}
+	comp.Parameters = kin.ParametersMap{}
+	comp.RequestBodies = kin.RequestBodies{}
+	comp.Responses = kin.ResponseBodies{}
+
+	overrides := make(map[reflect.Type]*kin.Schema, len(builtinTypeOverrides)+len(cfg.TypeOverrides))
+	for t, schema := range builtinTypeOverrides {
+		overrides[t] = schema
+	}
+	for t, schema := range cfg.TypeOverrides {
+		overrides[t] = schema
+	}
 
 	return &generator{
 		doc: kin.T{
 			OpenAPI:    "3.0.0",
 			Components: &comp,
 		},
-		gen: kingen.NewGenerator(kingen.SchemaCustomizer(customizer)),
+		objPkgSegments:  cfg.ObjPkgSegments,
+		typeOverrides:   overrides,
+		implementations: cfg.Implementations,
+		validationTag:   cfg.ValidationTag,
+		embeddedAllOf:   cfg.EmbeddedAllOf,
+		required:        cfg.Required,
+		inputOnly:       map[string]bool{},
 	}
 }
 
@@ -107,28 +238,171 @@ func (g *generator) AddOperation(method, path string, op Operation) error {
 	if err != nil {
 		return fmt.Errorf("generating security requirement for %s %q: %w", method, path, err)
 	}
+	if op.public {
+		secReqs = &kin.SecurityRequirements{}
+	}
+
+	exts, err := extensions(op.extensions)
+	if err != nil {
+		return fmt.Errorf("generating extensions for %s %q: %w", method, path, err)
+	}
+
+	var servers *kin.Servers
+	if len(op.servers) > 0 {
+		servers = &kin.Servers{}
+		for _, srv := range op.servers {
+			*servers = append(*servers, &kin.Server{
+				URL:         srv.URL,
+				Description: srv.Description,
+			})
+		}
+	}
 
 	g.doc.AddOperation(path, method, &kin.Operation{
-		Summary:     op.doc,
-		OperationID: op.id,
-		Tags:        op.tags,
-		Parameters:  params,
-		RequestBody: reqBody,
-		Responses:   responses,
-		Security:    secReqs,
+		Extensions:   exts,
+		Summary:      op.doc,
+		Description:  op.desc,
+		OperationID:  op.id,
+		Tags:         op.tags,
+		Parameters:   params,
+		RequestBody:  reqBody,
+		Responses:    responses,
+		Security:     secReqs,
+		Servers:      servers,
+		ExternalDocs: op.extDocs,
 	})
 	return nil
 }
 
+// extensions validates and copies the given vendor extensions.
+func extensions(exts map[string]any) (map[string]any, error) {
+	if len(exts) == 0 {
+		return nil, nil
+	}
+
+	ret := make(map[string]any, len(exts))
+	for k, v := range exts {
+		if !strings.HasPrefix(k, "x-") {
+			return nil, fmt.Errorf("extension %q must start with \"x-\"", k)
+		}
+		ret[k] = v
+	}
+	return ret, nil
+}
+
+// newSchemaRef generates the schema of the given object.
+//
+// A new schema generator is used for each object, as the customizer
+// can generate the schemas of other objects while one is being generated.
+func (g *generator) newSchemaRef(obj any) (*kin.SchemaRef, error) {
+	structs := map[string]*kin.Schema{}
+	gen := kingen.NewGenerator(
+		kingen.CreateTypeNameGenerator(g.typeName),
+		kingen.SchemaCustomizer(func(name string, t reflect.Type, tag reflect.StructTag, schema *kin.Schema) error {
+			// Pointer fields are nullable, which is not a property of the type.
+			nullable := schema.Nullable
+			schema.Nullable = false
+			if err := g.customize(name, t, tag, schema); err != nil {
+				return err
+			}
+
+			if t.Kind() == reflect.Struct {
+				// The schema of the type is kept before the field using it
+				// customizes it, to be registered as its component.
+				typeSchema, err := cloneSchema(schema)
+				if err != nil {
+					return err
+				}
+				structs[g.typeName(t)] = typeSchema
+			}
+			schema.Nullable = schema.Nullable || nullable
+			return nil
+		}),
+	)
+
+	ref, err := gen.NewSchemaRefForValue(obj, g.doc.Components.Schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	g.registerCycles(ref, structs, map[*kin.Schema]bool{})
+	return ref, nil
+}
+
+// registerCycles registers the component schemas that the generator
+// referenced to break a cycle, but that are not registered yet.
+func (g *generator) registerCycles(ref *kin.SchemaRef, structs map[string]*kin.Schema, seen map[*kin.Schema]bool) {
+	if ref == nil {
+		return
+	}
+	if name, ok := strings.CutPrefix(ref.Ref, "#/components/schemas/"); ok {
+		if _, ok = g.doc.Components.Schemas[name]; ok {
+			return
+		}
+		schema, ok := structs[name]
+		if !ok {
+			return
+		}
+
+		g.doc.Components.Schemas[name] = &kin.SchemaRef{Value: schema}
+		ref = &kin.SchemaRef{Value: schema}
+	}
+
+	schema := ref.Value
+	if schema == nil || seen[schema] {
+		return
+	}
+	seen[schema] = true
+
+	for _, prop := range schema.Properties {
+		g.registerCycles(prop, structs, seen)
+	}
+	for _, refs := range []kin.SchemaRefs{schema.AllOf, schema.AnyOf, schema.OneOf} {
+		for _, r := range refs {
+			g.registerCycles(r, structs, seen)
+		}
+	}
+	g.registerCycles(schema.Items, structs, seen)
+	g.registerCycles(schema.AdditionalProperties.Schema, structs, seen)
+	g.registerCycles(schema.Not, structs, seen)
+}
+
 func (g *generator) schema(obj any) (*kin.SchemaRef, error) {
+	if p, ok := obj.(patch); ok {
+		return g.patchSchema(p)
+	}
+
 	t := reflect.TypeOf(obj)
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
-	if t.Kind() != reflect.Struct {
-		return g.gen.NewSchemaRefForValue(obj, g.doc.Components.Schemas)
+	if t.Kind() != reflect.Struct || g.isOverridden(t) {
+		return g.newSchemaRef(obj)
 	}
 
+	name := g.typeName(t)
+	if _, ok := g.doc.Components.Schemas[name]; ok {
+		return &kin.SchemaRef{Ref: "#/components/schemas/" + name}, nil
+	}
+
+	if !isExported(t.Name()) {
+		return g.newSchemaRef(obj)
+	}
+
+	schema, err := registerSchema(g.doc.Components.Schemas, name, &kin.Schema{}, func() (*kin.SchemaRef, error) {
+		return g.newSchemaRef(obj)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if schema.Value == nil {
+		return schema, nil
+	}
+	return &kin.SchemaRef{Ref: "#/components/schemas/" + name}, nil
+}
+
+// typeName returns the component name of the given type.
+func (g *generator) typeName(t reflect.Type) string {
 	name := t.Name()
 	if from, to := strings.Index(name, "["), strings.LastIndex(name, "]"); from != -1 && from < to {
 		// name: "Object[github.com/org/repo/pkg.struct]".
@@ -154,22 +428,7 @@ func (g *generator) schema(obj any) (*kin.SchemaRef, error) {
 		}
 		name = strings.Join(parts, ".") + "." + name
 	}
-
-	if _, ok := g.doc.Components.Schemas[name]; ok {
-		return &kin.SchemaRef{Ref: "#/components/schemas/" + name}, nil
-	}
-
-	schema, err := g.gen.NewSchemaRefForValue(obj, g.doc.Components.Schemas)
-	if err != nil {
-		return nil, err
-	}
-	if schema.Value == nil || !isExported(t.Name()) {
-		return schema, nil
-	}
-
-	g.doc.Components.Schemas[name] = schema
-
-	return &kin.SchemaRef{Ref: "#/components/schemas/" + name}, nil
+	return name
 }
 
 func isExported(name string) bool {
@@ -205,37 +464,128 @@ func (g *generator) toParams(params []Parameter) (kin.Parameters, error) {
 			}
 		}
 
-		ret[i] = &kin.ParameterRef{Value: &kin.Parameter{
+		exts, err := extensions(param.extensions)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", param.name, err)
+		}
+
+		p := &kin.Parameter{
+			Extensions:  exts,
 			Name:        param.name,
 			In:          param.in,
 			Description: param.description,
 			Required:    param.required,
 			Schema:      schema,
-		}}
+		}
+		if param.component == "" {
+			ret[i] = &kin.ParameterRef{Value: p}
+			continue
+		}
+
+		if err = addComponent(g.doc.Components.Parameters, param.component, &kin.ParameterRef{Value: p}); err != nil {
+			return nil, err
+		}
+		ret[i] = &kin.ParameterRef{Ref: "#/components/parameters/" + param.component}
 	}
 	return ret, nil
 }
 
-func (g *generator) toRequestBody(obj any, mediaTypes []string) (*kin.RequestBodyRef, error) {
-	if obj == nil || len(mediaTypes) == 0 {
+func (g *generator) toRequestBody(body *RequestBody, mediaTypes []string) (*kin.RequestBodyRef, error) {
+	if body == nil {
 		//nolint:nilnil
 		return nil, nil
 	}
 
-	schema, err := g.schema(obj)
+	content, err := g.requestBodyContent(body, mediaTypes)
 	if err != nil {
 		return nil, err
 	}
+	if len(content) == 0 {
+		//nolint:nilnil
+		return nil, nil
+	}
+	applyEncodings(content, body.encodings)
+
+	reqBody := &kin.RequestBody{
+		Description: body.description,
+		Required:    !body.optional,
+		Content:     content,
+	}
+	if body.component == "" {
+		return &kin.RequestBodyRef{Value: reqBody}, nil
+	}
+
+	if err = addComponent(g.doc.Components.RequestBodies, body.component, &kin.RequestBodyRef{Value: reqBody}); err != nil {
+		return nil, err
+	}
+	return &kin.RequestBodyRef{Ref: "#/components/requestBodies/" + body.component}, nil
+}
+
+// requestBodyContent returns the content of the request body, reading the
+// request body type for the given media types and the types set per media type.
+func (g *generator) requestBodyContent(body *RequestBody, mediaTypes []string) (kin.Content, error) {
+	if p, ok := body.reads.(patch); ok {
+		mediaTypes = []string{p.mediaType()}
+	}
 
 	content := kin.Content{}
-	for _, mime := range mediaTypes {
+	if body.reads != nil {
+		schema, err := g.schema(body.reads)
+		if err != nil {
+			return nil, err
+		}
+
+		var examples kin.Examples
+		for name, val := range body.examples {
+			if examples == nil {
+				examples = kin.Examples{}
+			}
+			examples[name] = &kin.ExampleRef{Value: kin.NewExample(val)}
+		}
+
+		for _, mime := range mediaTypes {
+			content[mime] = &kin.MediaType{Schema: schema, Examples: examples}
+		}
+	}
+
+	mimes := make([]string, 0, len(body.mediaTypes))
+	for mime := range body.mediaTypes {
+		mimes = append(mimes, mime)
+	}
+	sort.Strings(mimes)
+	for _, mime := range mimes {
+		obj := body.mediaTypes[mime]
+		if obj == nil {
+			content[mime] = &kin.MediaType{}
+			continue
+		}
+
+		schema, err := g.schema(obj)
+		if err != nil {
+			return nil, err
+		}
 		content[mime] = &kin.MediaType{Schema: schema}
 	}
+	return content, nil
+}
+
+// applyEncodings sets the encodings of the given properties
+// on the multipart and form media types of the content.
+func applyEncodings(content kin.Content, encodings map[string]string) {
+	if len(encodings) == 0 {
+		return
+	}
 
-	return &kin.RequestBodyRef{Value: &kin.RequestBody{
-		Required: true,
-		Content:  content,
-	}}, nil
+	for mime, mt := range content {
+		if !strings.HasPrefix(mime, "multipart/") && mime != "application/x-www-form-urlencoded" {
+			continue
+		}
+
+		mt.Encoding = make(map[string]*kin.Encoding, len(encodings))
+		for prop, contentType := range encodings {
+			mt.Encoding[prop] = &kin.Encoding{ContentType: contentType}
+		}
+	}
 }
 
 func (g *generator) toResponses(res []Response, mediaTypes []string) (*kin.Responses, error) {
@@ -245,99 +595,213 @@ func (g *generator) toResponses(res []Response, mediaTypes []string) (*kin.Respo
 
 	responses := &kin.Responses{}
 	for _, r := range res {
-		if r.writes == nil {
-			responses.Set(strconv.Itoa(r.code), &kin.ResponseRef{Value: &kin.Response{
-				Description: &r.description,
-			}})
-			continue
-		}
-
-		schema, err := g.schema(r.writes)
+		resp, err := g.toResponse(r, mediaTypes)
 		if err != nil {
 			return nil, err
 		}
 
-		useMediaTypes := mediaTypes
-		if len(r.mediaTypes) > 0 {
-			useMediaTypes = r.mediaTypes
+		if r.component == "" {
+			responses.Set(strconv.Itoa(r.code), &kin.ResponseRef{Value: resp})
+			continue
 		}
 
-		content := kin.Content{}
-		for _, mime := range useMediaTypes {
-			content[mime] = &kin.MediaType{Schema: schema}
+		if err = addComponent(g.doc.Components.Responses, r.component, &kin.ResponseRef{Value: resp}); err != nil {
+			return nil, err
 		}
+		responses.Set(strconv.Itoa(r.code), &kin.ResponseRef{Ref: "#/components/responses/" + r.component})
+	}
+	return responses, nil
+}
 
-		headers := make(kin.Headers, len(r.headers))
-		for _, name := range r.headers {
-			headers[name] = &kin.HeaderRef{
-				Value: &kin.Header{
-					Parameter: kin.Parameter{
-						Name: name,
-						In:   kin.ParameterInHeader,
-					},
+func (g *generator) toResponse(r Response, mediaTypes []string) (*kin.Response, error) {
+	exts, err := extensions(r.extensions)
+	if err != nil {
+		return nil, fmt.Errorf("response %d: %w", r.code, err)
+	}
+
+	if r.writes == nil {
+		return &kin.Response{
+			Extensions:  exts,
+			Description: &r.description,
+		}, nil
+	}
+
+	schema, err := g.schema(r.writes)
+	if err != nil {
+		return nil, err
+	}
+
+	useMediaTypes := mediaTypes
+	if len(r.mediaTypes) > 0 {
+		useMediaTypes = r.mediaTypes
+	}
+
+	content := kin.Content{}
+	for _, mime := range useMediaTypes {
+		content[mime] = &kin.MediaType{Schema: schema}
+	}
+
+	headers := make(kin.Headers, len(r.headers))
+	for _, name := range r.headers {
+		headers[name] = &kin.HeaderRef{
+			Value: &kin.Header{
+				Parameter: kin.Parameter{
+					Name: name,
+					In:   kin.ParameterInHeader,
 				},
-			}
+			},
 		}
+	}
 
-		responses.Set(strconv.Itoa(r.code), &kin.ResponseRef{Value: &kin.Response{
-			Description: &r.description,
-			Content:     content,
-			Headers:     headers,
-		}})
+	return &kin.Response{
+		Extensions:  exts,
+		Description: &r.description,
+		Content:     content,
+		Headers:     headers,
+	}, nil
+}
+
+// registerSchema registers the schema under the given name and replaces it
+// with the schema returned by fill. The schema is registered before it is
+// filled, so recursive references to it can refer to the component.
+// The component is removed if fill fails or returns no schema value.
+func registerSchema(schemas kin.Schemas, name string, schema *kin.Schema, fill func() (*kin.SchemaRef, error)) (*kin.SchemaRef, error) {
+	schemas[name] = &kin.SchemaRef{Value: schema}
+
+	ref, err := fill()
+	if err != nil {
+		delete(schemas, name)
+		return nil, err
 	}
-	return responses, nil
+	if ref.Value == nil {
+		delete(schemas, name)
+		return ref, nil
+	}
+	schemas[name] = ref
+	return ref, nil
 }
 
-// addSecuritySchemes derives a security scheme from the given Security struct and returns the security requirements,
-// which act as a reference from an endpoint to its security scheme.
-func (g *generator) addSecuritySchemes(secs map[string]Security) (*kin.SecurityRequirements, error) {
-	if len(secs) == 0 {
+// addComponent adds the component under the given name. A component
+// can be added more than once, as long as its definition does not change.
+func addComponent[T any](comps map[string]T, name string, comp T) error {
+	existing, ok := comps[name]
+	if !ok {
+		comps[name] = comp
+		return nil
+	}
+
+	a, err := json.Marshal(existing)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(comp)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(a, b) {
+		return fmt.Errorf("component %q is defined more than once with different definitions", name)
+	}
+	return nil
+}
+
+// addSecuritySchemes derives the security schemes from the given security groups and returns the security requirements,
+// which act as a reference from an endpoint to its security schemes.
+//
+// Each group becomes a security requirement, in the order they were declared.
+func (g *generator) addSecuritySchemes(groups []securityGroup) (*kin.SecurityRequirements, error) {
+	if len(groups) == 0 {
 		return nil, nil //nolint:nilnil
 	}
 
-	var reqs kin.SecurityRequirements
-	for name, sec := range secs {
-		switch sec.Type {
-		case secTypeBasic:
-			g.doc.Components.SecuritySchemes[name] = &kin.SecuritySchemeRef{
-				Value: &kin.SecurityScheme{
-					Type:   "http",
-					Scheme: "basic",
-				},
+	reqs := make(kin.SecurityRequirements, 0, len(groups))
+	for _, group := range groups {
+		req := kin.SecurityRequirement{}
+		for _, r := range group {
+			scheme, err := securityScheme(r.Security)
+			if err != nil {
+				return nil, err
 			}
-			reqs = append(reqs, kin.SecurityRequirement{
-				name: []string{},
-			})
-		case secTypeBearer:
-			g.doc.Components.SecuritySchemes[name] = &kin.SecuritySchemeRef{
-				Value: &kin.SecurityScheme{
-					BearerFormat: sec.BearerFormat,
-					Type:         "http",
-					Scheme:       "bearer",
-				},
+			if err = addComponent(g.doc.Components.SecuritySchemes, r.Name, &kin.SecuritySchemeRef{Value: scheme}); err != nil {
+				return nil, err
 			}
-			reqs = append(reqs, kin.SecurityRequirement{
-				name: []string{},
-			})
-		case secTypeAPIKey:
-			g.doc.Components.SecuritySchemes[name] = &kin.SecuritySchemeRef{
-				Value: &kin.SecurityScheme{
-					Type: "apiKey",
-					Name: sec.APIKeyName,
-					In:   sec.APIKeyIn,
-				},
+
+			scopes := r.Scopes
+			if scopes == nil {
+				scopes = []string{}
 			}
-			reqs = append(reqs, kin.SecurityRequirement{
-				name: []string{},
-			})
-		default:
-			return nil, fmt.Errorf("unsupported security type %q", sec.Type)
+			req[r.Name] = scopes
 		}
+		reqs = append(reqs, req)
 	}
 
 	return &reqs, nil
 }
 
+func securityScheme(sec Security) (*kin.SecurityScheme, error) {
+	switch sec.Type {
+	case secTypeBasic:
+		return &kin.SecurityScheme{
+			Type:   "http",
+			Scheme: "basic",
+		}, nil
+	case secTypeBearer:
+		return &kin.SecurityScheme{
+			BearerFormat: sec.BearerFormat,
+			Type:         "http",
+			Scheme:       "bearer",
+		}, nil
+	case secTypeAPIKey:
+		return &kin.SecurityScheme{
+			Type: "apiKey",
+			Name: sec.APIKeyName,
+			In:   sec.APIKeyIn,
+		}, nil
+	case secTypeOAuth2:
+		if sec.Flows == nil {
+			return nil, errors.New("oauth2 security requires flows")
+		}
+		return &kin.SecurityScheme{
+			Type: "oauth2",
+			Flows: &kin.OAuthFlows{
+				Implicit:          oauthFlow(sec.Flows.Implicit),
+				Password:          oauthFlow(sec.Flows.Password),
+				ClientCredentials: oauthFlow(sec.Flows.ClientCredentials),
+				AuthorizationCode: oauthFlow(sec.Flows.AuthorizationCode),
+			},
+		}, nil
+	case secTypeOpenIDConnect:
+		if sec.OpenIDConnectURL == "" {
+			return nil, errors.New("openIdConnect security requires a discovery url")
+		}
+		return &kin.SecurityScheme{
+			Type:             "openIdConnect",
+			OpenIdConnectUrl: sec.OpenIDConnectURL,
+		}, nil
+	case secTypeMutualTLS:
+		// The mutualTLS type was introduced in OpenAPI 3.1.
+		return nil, errors.New("mutualTLS security requires OpenAPI 3.1, specs are built as OpenAPI 3.0")
+	default:
+		return nil, fmt.Errorf("unsupported security type %q", sec.Type)
+	}
+}
+
+func oauthFlow(flow *OAuthFlow) *kin.OAuthFlow {
+	if flow == nil {
+		return nil
+	}
+
+	scopes := flow.Scopes
+	if scopes == nil {
+		scopes = map[string]string{}
+	}
+	return &kin.OAuthFlow{
+		AuthorizationURL: flow.AuthorizationURL,
+		TokenURL:         flow.TokenURL,
+		RefreshURL:       flow.RefreshURL,
+		Scopes:           scopes,
+	}
+}
+
 type openAPIType interface {
 	OpenAPISchemaType() []string
 	OpenAPISchemaFormat() string
@@ -347,11 +811,28 @@ type oneOfTypes interface {
 	OpenAPIV3OneOfTypes() []string
 }
 
+type enumerable interface {
+	OpenAPIEnum() []any
+}
+
+type enumDescribable interface {
+	OpenAPIEnumDescriptions() []string
+}
+
+type enumNamable interface {
+	OpenAPIEnumVarNames() []string
+}
+
 type docable interface {
 	Docs() map[string]string
 }
 
 type attrable interface {
+	Attributes() map[string][]string
+}
+
+// legacyAttrable is the single attribute per property form of attrable.
+type legacyAttrable interface {
 	Attributes() map[string]string
 }
 
@@ -359,9 +840,54 @@ type formatable interface {
 	Formats() map[string]string
 }
 
-func customizer(name string, t reflect.Type, _ reflect.StructTag, schema *kin.Schema) error {
+type defaultable interface {
+	Defaults() map[string]any
+}
+
+type examplable interface {
+	Examples() map[string]any
+}
+
+type titled interface {
+	OpenAPITitle() string
+}
+
+type described interface {
+	OpenAPIDescription() string
+}
+
+type typeExamplable interface {
+	OpenAPIExample() any
+}
+
+type typeDefaultable interface {
+	OpenAPIDefault() any
+}
+
+type extensible interface {
+	OpenAPIExtensions() map[string]any
+}
+
+func (g *generator) customize(name string, t reflect.Type, _ reflect.StructTag, schema *kin.Schema) error {
+	if ok, err := g.applyTypeOverride(t, schema); ok || err != nil {
+		return err
+	}
+
 	v := reflect.New(t).Elem().Interface()
+	if err := g.applyTypeLevel(name, t, schema, v); err != nil {
+		return err
+	}
 
+	if t.Kind() != reflect.Struct {
+		applyPropertyMethods(schema, v)
+		return nil
+	}
+	return g.applyStructLevel(t, schema, v)
+}
+
+// applyTypeLevel applies the customizations defined by the type
+// of the given object, regardless of its kind, to the schema.
+func (g *generator) applyTypeLevel(name string, t reflect.Type, schema *kin.Schema, v any) error {
 	if obj, ok := v.(openAPIType); ok {
 		if err := applyType(name, schema, obj); err != nil {
 			return err
@@ -372,18 +898,133 @@ func customizer(name string, t reflect.Type, _ reflect.StructTag, schema *kin.Sc
 		applyOneOfTypes(schema, obj)
 	}
 
+	if obj, ok := v.(enumerable); ok {
+		if err := applyEnum(name, schema, obj); err != nil {
+			return err
+		}
+	}
+
+	if impls, ok := g.implementationsOf(t, v); ok {
+		if err := g.applyImplementations(name, schema, impls); err != nil {
+			return err
+		}
+	}
+
+	applyTypeMethods(schema, v)
+
+	if obj, ok := v.(extensible); ok {
+		return applyExtensions(name, schema, obj)
+	}
+	return nil
+}
+
+// applyStructLevel applies the customizations defined by the
+// fields and property methods of the given struct to the schema.
+func (g *generator) applyStructLevel(t reflect.Type, schema *kin.Schema, v any) error {
+	if g.required == RequiredInferred {
+		applyInferredRequired(schema, t)
+	}
+
+	if g.validationTag != "" {
+		applyValidation(schema, t, g.validationTag)
+	}
+
+	// Field tags are applied first, to be overridden by the type methods.
+	applyFieldTags(schema, t)
+
+	if g.embeddedAllOf {
+		applyPropertyMethods(schema, v)
+		return g.applyEmbeddedAllOf(schema, t)
+	}
+
+	// Embedded structs document their promoted properties,
+	// to be overridden by the embedding struct.
+	fields := propertyFields(t)
+	for _, e := range embeddedStructs(t) {
+		promoted := &kin.Schema{Properties: promotedProperties(schema, fields, e)}
+		applyPropertyMethods(promoted, reflect.New(e.Type).Elem().Interface())
+		addRequired(schema, promoted.Required...)
+	}
+	applyPropertyMethods(schema, v)
+
+	return nil
+}
+
+// applyPropertyMethods applies the property docs, attributes
+// and formats defined by the given object to the schema.
+func applyPropertyMethods(schema *kin.Schema, v any) {
 	if obj, ok := v.(docable); ok {
 		applyDocs(schema, obj)
 	}
 
-	if obj, ok := v.(attrable); ok {
-		applyAttrs(schema, obj)
+	if attrs, ok := attributes(v); ok {
+		applyAttrs(schema, attrs)
 	}
 
 	if obj, ok := v.(formatable); ok {
 		applyFormats(schema, obj)
 	}
 
+	if obj, ok := v.(defaultable); ok {
+		applyDefaults(schema, obj)
+	}
+
+	if obj, ok := v.(examplable); ok {
+		applyExamples(schema, obj)
+	}
+}
+
+// applyTypeMethods applies the title, description, example and
+// default defined by the given object to the schema.
+func applyTypeMethods(schema *kin.Schema, v any) {
+	if obj, ok := v.(titled); ok {
+		schema.Title = obj.OpenAPITitle()
+	}
+
+	if obj, ok := v.(described); ok {
+		schema.Description = obj.OpenAPIDescription()
+	}
+
+	if obj, ok := v.(typeExamplable); ok {
+		schema.Example = obj.OpenAPIExample()
+	}
+
+	if obj, ok := v.(typeDefaultable); ok {
+		schema.Default = obj.OpenAPIDefault()
+	}
+}
+
+// applyEmbeddedAllOf documents the structs directly embedded in the given
+// struct type as "allOf" their component schemas, removing their
+// promoted properties from the schema.
+func (g *generator) applyEmbeddedAllOf(schema *kin.Schema, t reflect.Type) error {
+	fields := propertyFields(t)
+	for _, e := range embeddedStructs(t) {
+		if len(e.Path) != 1 || !isExported(e.Type.Name()) {
+			continue
+		}
+
+		ref, err := g.schema(reflect.New(e.Type).Interface())
+		if err != nil {
+			return fmt.Errorf("generating embedded schema %q: %w", e.Type.Name(), err)
+		}
+		if ref.Ref == "" {
+			continue
+		}
+
+		for name := range promotedProperties(schema, fields, e) {
+			delete(schema.Properties, name)
+			schema.Required = slices.DeleteFunc(schema.Required, func(req string) bool {
+				return req == name
+			})
+		}
+		schema.AllOf = append(schema.AllOf, ref)
+	}
+
+	if len(schema.AllOf) > 0 && len(schema.Properties) == 0 {
+		schema.Type = nil
+		schema.Properties = nil
+	}
 	return nil
 }
 
@@ -406,6 +1047,36 @@ func applyOneOfTypes(schema *kin.Schema, obj oneOfTypes) {
 	schema.OneOf = refs
 }
 
+func applyEnum(name string, schema *kin.Schema, obj enumerable) error {
+	enum := obj.OpenAPIEnum()
+	if len(enum) == 0 {
+		return fmt.Errorf("type %q defines an enum but returns no values", name)
+	}
+	schema.Enum = enum
+
+	if o, ok := obj.(enumDescribable); ok {
+		descs := o.OpenAPIEnumDescriptions()
+		if len(descs) != len(enum) {
+			return fmt.Errorf("type %q defines %d enum descriptions for %d values", name, len(descs), len(enum))
+		}
+		if schema.Extensions == nil {
+			schema.Extensions = map[string]any{}
+		}
+		schema.Extensions["x-enum-descriptions"] = descs
+	}
+	if o, ok := obj.(enumNamable); ok {
+		names := o.OpenAPIEnumVarNames()
+		if len(names) != len(enum) {
+			return fmt.Errorf("type %q defines %d enum var names for %d values", name, len(names), len(enum))
+		}
+		if schema.Extensions == nil {
+			schema.Extensions = map[string]any{}
+		}
+		schema.Extensions["x-enum-varnames"] = names
+	}
+	return nil
+}
+
 func applyDocs(schema *kin.Schema, obj docable) {
 	docs := obj.Docs()
 	for k, prop := range schema.Properties {
@@ -421,25 +1092,128 @@ func applyDocs(schema *kin.Schema, obj docable) {
 	}
 }
 
-func applyAttrs(schema *kin.Schema, obj attrable) {
-	attrs := obj.Attributes()
+// Property attributes.
+const (
+	attrRequired   = "required"
+	attrOptional   = "optional"
+	attrReadOnly   = "readonly"
+	attrWriteOnly  = "writeonly"
+	attrNullable   = "nullable"
+	attrDeprecated = "deprecated"
+	attrSensitive  = "sensitive"
+)
+
+func applyAttrs(schema *kin.Schema, attrs map[string][]string) {
 	var required []string
 	for k, prop := range schema.Properties {
-		attr := attrs[k]
 		if prop.Value == nil {
 			continue
 		}
 
-		switch attr {
-		case "readonly":
-			prop.Value.ReadOnly = true
-		case "required":
-			required = append(required, k)
+		for _, attr := range attrs[k] {
+			if applyAttr(prop.Value, attr) {
+				required = append(required, k)
+			}
+		}
+	}
+	addRequired(schema, required...)
+}
+
+// applyAttr applies the attribute to the property schema, returning
+// true if the attribute requires the property.
+func applyAttr(prop *kin.Schema, attr string) bool {
+	switch attr {
+	case attrRequired:
+		return true
+	case attrReadOnly:
+		prop.ReadOnly = true
+	case attrWriteOnly:
+		prop.WriteOnly = true
+	case attrNullable:
+		prop.Nullable = true
+	case attrDeprecated:
+		prop.Deprecated = true
+	case attrSensitive:
+		if prop.Extensions == nil {
+			prop.Extensions = map[string]any{}
 		}
+		prop.Extensions["x-sensitive"] = true
 	}
-	if len(required) > 0 {
-		sort.Strings(required)
-		schema.Required = required
+	return false
+}
+
+// attributes returns the property attributes defined by the given object.
+func attributes(v any) (map[string][]string, bool) {
+	switch obj := v.(type) {
+	case attrable:
+		return obj.Attributes(), true
+	case legacyAttrable:
+		return legacyAttrs(obj.Attributes()), true
+	}
+	return nil, false
+}
+
+// legacyAttrs converts single attributes per property into attribute sets.
+// Multiple attributes can be separated by a comma.
+func legacyAttrs(attrs map[string]string) map[string][]string {
+	ret := make(map[string][]string, len(attrs))
+	for k, attr := range attrs {
+		for _, a := range strings.Split(attr, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				ret[k] = append(ret[k], a)
+			}
+		}
+	}
+	return ret
+}
+
+// addRequired adds the given properties to the required properties of the schema.
+func addRequired(schema *kin.Schema, names ...string) {
+	for _, name := range names {
+		if slices.Contains(schema.Required, name) {
+			continue
+		}
+		schema.Required = append(schema.Required, name)
+	}
+	sort.Strings(schema.Required)
+}
+
+func applyExtensions(name string, schema *kin.Schema, obj extensible) error {
+	exts, err := extensions(obj.OpenAPIExtensions())
+	if err != nil {
+		return fmt.Errorf("type %q: %w", name, err)
+	}
+
+	if len(exts) > 0 && schema.Extensions == nil {
+		schema.Extensions = make(map[string]any, len(exts))
+	}
+	for k, v := range exts {
+		schema.Extensions[k] = v
+	}
+	return nil
+}
+
+func applyDefaults(schema *kin.Schema, obj defaultable) {
+	defs := obj.Defaults()
+	for k, prop := range schema.Properties {
+		def, ok := defs[k]
+		if !ok || prop.Value == nil {
+			continue
+		}
+
+		prop.Value.Default = def
+	}
+}
+
+func applyExamples(schema *kin.Schema, obj examplable) {
+	examples := obj.Examples()
+	for k, prop := range schema.Properties {
+		example, ok := examples[k]
+		if !ok || prop.Value == nil {
+			continue
+		}
+
+		prop.Value.Example = example
 	}
 }
 
@@ -1,12 +1,20 @@
 package openapi_test
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"math/big"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
+	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gamefabric/openapi"
 	kin "github.com/getkin/kin-openapi/openapi3"
@@ -122,6 +130,44 @@ func TestBuildSpecSecurity(t *testing.T) {
 			APIKeyName: "foo",
 			APIKeyIn:   "query",
 		}).Build()).Post("/apikey-query", func(rw http.ResponseWriter, req *http.Request) {})
+
+		// OAuth2:
+		r.With(testOp("test-oauth2").RequiresAuth("myOAuth2", openapi.Security{
+			Type: "oauth2",
+			Flows: &openapi.OAuthFlows{
+				ClientCredentials: &openapi.OAuthFlow{
+					TokenURL: "https://auth.example.com/token",
+					Scopes:   map[string]string{"read": "Read access", "write": "Write access"},
+				},
+				AuthorizationCode: &openapi.OAuthFlow{
+					AuthorizationURL: "https://auth.example.com/authorize",
+					TokenURL:         "https://auth.example.com/token",
+					RefreshURL:       "https://auth.example.com/refresh",
+					Scopes:           map[string]string{"read": "Read access"},
+				},
+			},
+		}, "read", "write").Build()).Post("/oauth2", func(rw http.ResponseWriter, req *http.Request) {})
+
+		// OpenID Connect:
+		r.With(testOp("test-oidc").RequiresAuth("myOIDC", openapi.Security{
+			Type:             "openIdConnect",
+			OpenIDConnectURL: "https://auth.example.com/.well-known/openid-configuration",
+		}, "openid").Build()).Post("/oidc", func(rw http.ResponseWriter, req *http.Request) {})
+
+		// Combinations:
+		r.With(testOp("test-and").RequiresAllAuth(
+			openapi.Requirement("myHeaderAPIKey", openapi.Security{Type: "apiKey", APIKeyName: "Foo", APIKeyIn: "header"}),
+			openapi.Requirement("myBearerAuth", openapi.SecurityBearer),
+		).Build()).Post("/and", func(rw http.ResponseWriter, req *http.Request) {})
+		r.With(testOp("test-or").
+			RequiresAuth("myBasicAuth", openapi.SecurityBasic).
+			RequiresAuth("myBearerAuth", openapi.SecurityBearer).
+			Build()).Post("/or", func(rw http.ResponseWriter, req *http.Request) {})
+		r.With(testOp("test-optional").
+			RequiresAuth("myBearerAuth", openapi.SecurityBearer).
+			AllowsAnonymous().
+			Build()).Post("/optional", func(rw http.ResponseWriter, req *http.Request) {})
+		r.With(testOp("test-none").RequiresAuth("none", openapi.SecurityNone).Build()).Post("/none", func(rw http.ResponseWriter, req *http.Request) {})
 	})
 
 	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
@@ -134,6 +180,8 @@ func TestBuildSpecSecurity(t *testing.T) {
 		Title:   "Test Server",
 		Version: "1",
 	}
+	require.NoError(t, doc.Validate(context.Background()))
+
 	got, err := json.MarshalIndent(doc, "", "  ")
 	require.NoError(t, err)
 	if *update {
@@ -145,6 +193,612 @@ func TestBuildSpecSecurity(t *testing.T) {
 	assert.Equal(t, string(want), string(got))
 }
 
+func TestBuildSpecDefaultSecurity(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-default").
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Get("/default", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-public").
+		Public().
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Get("/public", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-basic").
+		RequiresAuth("myBasicAuth", openapi.SecurityBasic).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Get("/basic", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+		Security: [][]openapi.SecurityRequirement{
+			{openapi.Requirement("myBearerAuth", openapi.SecurityBearer)},
+		},
+	})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-default-security.json", doc)
+}
+
+func TestBuildSpecSecurityConflict(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-bearer").
+		RequiresAuth("myAuth", openapi.SecurityBearer).
+		Build()).Get("/bearer", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-basic").
+		RequiresAuth("myAuth", openapi.SecurityBasic).
+		Build()).Get("/basic", func(rw http.ResponseWriter, req *http.Request) {})
+
+	_, err := openapi.BuildSpec(mux, openapi.SpecConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `component "myAuth" is defined more than once with different definitions`)
+}
+
+func TestBuildSpecSecurityMutualTLS(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-mtls").
+		RequiresAuth("myMutualTLS", openapi.SecurityMutualTLS).
+		Build()).Get("/mtls", func(rw http.ResponseWriter, req *http.Request) {})
+
+	_, err := openapi.BuildSpec(mux, openapi.SpecConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mutualTLS security requires OpenAPI 3.1")
+}
+
+func TestBuildSpecRegistry(t *testing.T) {
+	handler := func(rw http.ResponseWriter, req *http.Request) {}
+
+	reg := &openapi.Registry{}
+	mux := chi.NewMux()
+	mux.Get("/items", openapi.Op().ID("list-items").BuildHandlerIn(reg)(handler))
+	mux.Get("/others", openapi.Op().ID("list-others").BuildHandlerIn(reg)(handler))
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{Registry: reg})
+	require.NoError(t, err)
+
+	require.NotNil(t, doc.Paths.Find("/items"))
+	assert.Equal(t, "list-items", doc.Paths.Find("/items").Get.OperationID)
+	require.NotNil(t, doc.Paths.Find("/others"))
+	assert.Equal(t, "list-others", doc.Paths.Find("/others").Get.OperationID)
+
+	doc, err = openapi.BuildSpec(mux, openapi.SpecConfig{})
+	require.NoError(t, err)
+
+	assert.Empty(t, doc.Paths.Map())
+}
+
+func TestBuildSpecTypeOverrides(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-types").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestTypesObject{}).
+		Returns(http.StatusAccepted, "Accepted", &url.URL{}).
+		Build()).Get("/types", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+		ObjPkgSegments: 1,
+		TypeOverrides: map[reflect.Type]*kin.Schema{
+			reflect.TypeOf(TestCustomObject{}): {Type: &kin.Types{"string"}, Format: "uuid"},
+		},
+	})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-types.json", doc)
+
+	// The schemas must match the actual JSON representation of the types.
+	addr := netip.MustParseAddr("10.0.0.1")
+	obj := &TestTypesObject{
+		Time:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Duration: time.Second,
+		Raw:      json.RawMessage(`{"a":1}`),
+		URL:      url.URL{Scheme: "https", Host: "example.com", Path: "/path"},
+		Addr:     &addr,
+		AddrPort: netip.MustParseAddrPort("10.0.0.1:80"),
+		Prefix:   netip.MustParsePrefix("10.0.0.0/8"),
+		IP:       net.ParseIP("10.0.0.2"),
+		Int:      *big.NewInt(42),
+		Float:    big.NewFloat(1.5),
+		Rat:      big.NewRat(1, 3),
+		Text:     TestTextObject{},
+		Custom:   TestCustomObject{},
+	}
+	b, err := json.Marshal(obj)
+	require.NoError(t, err)
+	var val any
+	require.NoError(t, json.Unmarshal(b, &val))
+
+	schema := doc.Components.Schemas["openapi_test.TestTypesObject"]
+	require.NotNil(t, schema)
+	assert.NoError(t, schema.Value.VisitJSON(val), string(b))
+}
+
+func TestBuildSpecPolymorphism(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-allocation").
+		Consumes("application/json").
+		Reads(&TestAllocation{}).
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestAllocationResult{}).
+		Build()).Post("/allocate", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+		ObjPkgSegments: 1,
+		Implementations: map[reflect.Type]openapi.Implementations{
+			reflect.TypeOf((*TestAllocationSpec)(nil)).Elem(): {
+				Types: []any{&TestFleetAllocation{}, &TestRoomAllocation{}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-polymorphism.json", doc)
+}
+
+func TestBuildSpecEnum(t *testing.T) {
+	type options struct {
+		Region TestRegion `json:"region"`
+		Limit  int        `json:"limit"`
+	}
+
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-enum").
+		Param(openapi.QueryParameter("region", "the region", TestRegion(""))).
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestRegionObject{}).
+		Build()).Get("/region", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-enum-parsed").
+		Params(openapi.ParseParams(options{}, "")...).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Get("/parsed", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-enum.json", doc)
+}
+
+func TestBuildSpecValidation(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-validation").
+		Consumes("application/json").
+		Reads(&TestValidatedObject{}).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Post("/validated", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+		ObjPkgSegments: 1,
+		ValidationTag:  "validate",
+	})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-validation.json", doc)
+}
+
+func TestBuildSpecFieldTags(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-field-tags").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestTaggedObject{}).
+		Build()).Get("/tagged", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-field-tags.json", doc)
+}
+
+func TestBuildSpecEmbedded(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-embedded").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestFleet{}).
+		Build()).Get("/fleet", func(rw http.ResponseWriter, req *http.Request) {})
+
+	tests := []struct {
+		name   string
+		allOf  bool
+		golden string
+	}{
+		{
+			name:   "promoted",
+			golden: "testdata/spec-embedded.json",
+		},
+		{
+			name:   "all of",
+			allOf:  true,
+			golden: "testdata/spec-embedded-allof.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+				ObjPkgSegments: 1,
+				EmbeddedAllOf:  test.allOf,
+			})
+			require.NoError(t, err)
+
+			assertGoldenSpec(t, test.golden, doc)
+		})
+	}
+}
+
+func TestBuildSpecAttributes(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-attributes").
+		Consumes("application/json").
+		Reads(&TestAttributedObject{}).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Post("/attributes", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-attributes.json", doc)
+}
+
+func TestBuildSpecRequiredInferred(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-required").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestInferredObject{}).
+		Build()).Get("/required", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-required-legacy").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestInferredLegacyObject{}).
+		Build()).Get("/required-legacy", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+		ObjPkgSegments: 1,
+		Required:       openapi.RequiredInferred,
+	})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-required.json", doc)
+}
+
+func TestBuildSpecRecursive(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-tree").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestTree{}).
+		Build()).Get("/tree", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-node").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestTreeNode{}).
+		Build()).Get("/node", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-edge").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestTreeEdge{}).
+		Build()).Get("/edge", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+		ObjPkgSegments: 1,
+		Implementations: map[reflect.Type]openapi.Implementations{
+			reflect.TypeOf((*TestTreeValue)(nil)).Elem(): {
+				Types: []any{"", &TestTreeNode{}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-recursive.json", doc)
+}
+
+func TestBuildSpecRecursiveOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		objs   []any
+		golden string
+	}{
+		{
+			name:   "edge first",
+			objs:   []any{&TestTreeEdge{}, &TestTreeNode{}},
+			golden: "testdata/spec-recursive-edge-first.json",
+		},
+		{
+			name:   "tree first",
+			objs:   []any{&TestTree{}, &TestTreeNode{}},
+			golden: "testdata/spec-recursive-tree-first.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mux := chi.NewMux()
+			mux.With(openapi.Op().
+				ID("test-first").
+				Produces("application/json").
+				Returns(http.StatusOK, "OK", test.objs[0]).
+				Build()).Get("/a", func(rw http.ResponseWriter, req *http.Request) {})
+			mux.With(openapi.Op().
+				ID("test-second").
+				Produces("application/json").
+				Returns(http.StatusOK, "OK", test.objs[1]).
+				Build()).Get("/b", func(rw http.ResponseWriter, req *http.Request) {})
+
+			doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+			require.NoError(t, err)
+
+			node := doc.Components.Schemas["openapi_test.TestTreeNode"]
+			require.NotNil(t, node)
+			assert.False(t, node.Value.Nullable)
+			assert.Empty(t, node.Value.Description)
+
+			assertGoldenSpec(t, test.golden, doc)
+		})
+	}
+}
+
+func TestBuildSpecSchemaMetadata(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-metadata").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestDescribedObject{}).
+		Build()).Get("/described", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-metadata.json", doc)
+}
+
+func TestBuildSpecExtensions(t *testing.T) {
+	mux := chi.NewMux()
+	mux.Use(openapi.Op().
+		Extension("x-stability", "beta").
+		Extension("x-internal", false).
+		Build())
+
+	mux.With(openapi.Op().
+		ID("test-extensions").
+		Extension("x-rate-limit", map[string]any{"requests": 100, "window": "1m"}).
+		Extension("x-stability", "stable").
+		Param(openapi.PathParameter("name", "the item name", openapi.WithParameterExtension("x-codegen-name", "itemName"))).
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestExtendedObject{}, openapi.WithResponseExtension("x-codegen-response", "Item")).
+		Build()).Get("/extensions/{name}", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-extensions.json", doc)
+}
+
+func TestBuildSpecExtensionsInvalidKey(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-extensions").
+		Extension("rate-limit", 100).
+		Build()).Get("/extensions", func(rw http.ResponseWriter, req *http.Request) {})
+
+	_, err := openapi.BuildSpec(mux, openapi.SpecConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `extension "rate-limit" must start with "x-"`)
+}
+
+func TestBuildSpecOperationDocs(t *testing.T) {
+	mux := chi.NewMux()
+	mux.Use(openapi.Op().
+		Description("Overridden by the route.").
+		ExternalDocs("https://docs.example.com", "The API docs").
+		Build())
+
+	mux.With(openapi.Op().
+		ID("test-upload").
+		Doc("Upload a file").
+		Description("Uploads a file.\n\nThe file is stored on the **upload** servers.").
+		Servers(
+			openapi.Server{URL: "https://upload.example.com", Description: "The upload server"},
+			openapi.Server{URL: "https://upload-eu.example.com"},
+		).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Post("/upload", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-operation.json", doc)
+}
+
+func TestBuildSpecComponents(t *testing.T) {
+	nameParam := openapi.PathParameter("name", "the item name", openapi.WithParameterComponent("ItemName"))
+	notFound := openapi.WithResponseComponent("NotFound")
+
+	mux := chi.NewMux()
+	mux.Use(openapi.Op().
+		Consumes("application/json").
+		Produces("application/json").
+		Build())
+
+	mux.With(openapi.Op().
+		ID("test-get").
+		Param(nameParam).
+		Returns(http.StatusOK, "OK", &TestObject{}).
+		Returns(http.StatusNotFound, "The item was not found", &TestSimpleObject{}, notFound).
+		Build()).Get("/items/{name}", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-put").
+		Param(nameParam).
+		Reads(&TestObject{}, openapi.WithRequestBodyComponent("Item")).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Returns(http.StatusNotFound, "The item was not found", &TestSimpleObject{}, notFound).
+		Build()).Put("/items/{name}", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-post").
+		Reads(&TestObject{}, openapi.WithRequestBodyComponent("Item")).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Post("/items", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-components.json", doc)
+}
+
+func TestBuildSpecComponentsConflict(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-get").
+		Param(openapi.PathParameter("name", "the item name", openapi.WithParameterComponent("Name"))).
+		Build()).Get("/items/{name}", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-get-other").
+		Param(openapi.PathParameter("name", "the other name", openapi.WithParameterComponent("Name"))).
+		Build()).Get("/others/{name}", func(rw http.ResponseWriter, req *http.Request) {})
+
+	_, err := openapi.BuildSpec(mux, openapi.SpecConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `component "Name" is defined more than once with different definitions`)
+}
+
+func TestBuildSpecRequestBody(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-patch").
+		Consumes("application/json").
+		Reads(&TestSimpleObject{}, openapi.WithOptionalRequestBody(), openapi.WithRequestBodyDescription("The fields to update.")).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Patch("/items", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-upload").
+		Consumes("application/json", "application/yaml").
+		Reads(&TestSimpleObject{},
+			openapi.WithRequestBodyMediaType("text/plain", ""),
+			openapi.WithRequestBodyExample("simple", map[string]any{"test1": "value"}),
+		).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Post("/upload", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-multipart").
+		Consumes("multipart/form-data").
+		Reads(&TestSimpleObject{}, openapi.WithRequestBodyEncoding("test1", "image/png")).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Post("/multipart", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-binary").
+		Reads(nil, openapi.WithRequestBodyMediaType("application/octet-stream", nil)).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Put("/binary", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-request-body.json", doc)
+}
+
+func TestBuildSpecPatch(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-merge-patch").
+		Reads(openapi.MergePatchOf(&TestPatchable{})).
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestPatchable{}).
+		Build()).Patch("/merge", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-json-patch").
+		Consumes("application/json").
+		Reads(openapi.JSONPatch()).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Patch("/json", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-patch.json", doc)
+}
+
+func TestBuildSpecPatchComposed(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-patch-fleet").
+		Reads(openapi.MergePatchOf(&TestFleet{})).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Patch("/fleets", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-patch-result").
+		Reads(openapi.MergePatchOf(&TestAllocationResult{})).
+		Returns(http.StatusNoContent, "No Content", nil).
+		Build()).Patch("/results", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+		ObjPkgSegments: 1,
+		EmbeddedAllOf:  true,
+	})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-patch-composed.json", doc)
+
+	// Partial patches must be valid.
+	b, err := json.Marshal(&doc)
+	require.NoError(t, err)
+	loaded, err := kin.NewLoader().LoadFromData(b)
+	require.NoError(t, err)
+
+	schema := loaded.Components.Schemas["openapi_test.TestFleetMergePatch"]
+	require.NotNil(t, schema)
+	assert.NoError(t, schema.Value.VisitJSON(map[string]any{"replicas": float64(3)}))
+}
+
+func TestBuildSpecInputSchemas(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test-create").
+		Consumes("application/json").
+		Reads(&TestAccount{}).
+		Produces("application/json").
+		Returns(http.StatusCreated, "Created", &TestAccount{}).
+		Build()).Post("/accounts", func(rw http.ResponseWriter, req *http.Request) {})
+	mux.With(openapi.Op().
+		ID("test-list").
+		Produces("application/json").
+		Returns(http.StatusOK, "OK", &TestAccountList{}).
+		Build()).Get("/accounts", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1, InputSchemas: true})
+	require.NoError(t, err)
+
+	assertGoldenSpec(t, "testdata/spec-input-schemas.json", doc)
+}
+
+func assertGoldenSpec(t *testing.T, name string, doc kin.T) {
+	t.Helper()
+
+	doc.OpenAPI = "3.0.0"
+	doc.Info = &kin.Info{
+		Title:   "Test Server",
+		Version: "1",
+	}
+	got, err := json.MarshalIndent(doc, "", "  ")
+	require.NoError(t, err)
+	if *update {
+		_ = os.WriteFile(name, got, 0o644)
+	}
+
+	want, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
 func testHandler() http.HandlerFunc {
 	type options struct {
 		PageSize int    `schema:"page_size"`
@@ -200,3 +854,384 @@ func (TestObject) Formats() map[string]string {
 		"test4": "ipv4",
 	}
 }
+
+func TestBuildSpecFormats(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test").
+		Returns(http.StatusOK, "OK", &TestFormattedObject{}).
+		Build()).Get("/test", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{ObjPkgSegments: 1})
+	require.NoError(t, err)
+
+	schema := doc.Components.Schemas["openapi_test.TestFormattedObject"]
+	require.NotNil(t, schema)
+	assert.Equal(t, "ipv4", schema.Value.Properties["addr"].Value.Format)
+	// The formats method sets the formats of all properties.
+	assert.Empty(t, schema.Value.Properties["count"].Value.Format)
+	assert.Empty(t, schema.Value.Properties["id"].Value.Format)
+}
+
+type TestFormattedObject struct {
+	Addr  string `json:"addr"`
+	Count int64  `json:"count"`
+	ID    string `json:"id" openapi:"format=uuid"`
+}
+
+func (TestFormattedObject) Formats() map[string]string {
+	return map[string]string{
+		"addr": "ipv4",
+	}
+}
+
+func TestBuildSpecTypeOverridesCopied(t *testing.T) {
+	override := &kin.Schema{
+		Type:       &kin.Types{"string"},
+		Format:     "uuid",
+		Extensions: map[string]any{"x-test": "value"},
+	}
+
+	mux := chi.NewMux()
+	mux.With(openapi.Op().
+		ID("test").
+		Returns(http.StatusOK, "OK", &TestOverriddenFieldsObject{}).
+		Build()).Get("/test", func(rw http.ResponseWriter, req *http.Request) {})
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{
+		ObjPkgSegments: 1,
+		TypeOverrides: map[reflect.Type]*kin.Schema{
+			reflect.TypeOf(TestCustomObject{}): override,
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &kin.Schema{
+		Type:       &kin.Types{"string"},
+		Format:     "uuid",
+		Extensions: map[string]any{"x-test": "value"},
+	}, override)
+
+	schema := doc.Components.Schemas["openapi_test.TestOverriddenFieldsObject"]
+	require.NotNil(t, schema)
+	first, second := schema.Value.Properties["first"].Value, schema.Value.Properties["second"].Value
+	assert.Equal(t, "The first ID.", first.Description)
+	assert.Equal(t, map[string]any{"x-test": "value", "x-sensitive": true}, first.Extensions)
+	assert.False(t, first.Nullable)
+	assert.Empty(t, second.Description)
+	assert.Equal(t, map[string]any{"x-test": "value"}, second.Extensions)
+	assert.True(t, second.Nullable)
+}
+
+type TestOverriddenFieldsObject struct {
+	First  TestCustomObject  `json:"first" openapi:"description=The first ID.,sensitive"`
+	Second *TestCustomObject `json:"second"`
+}
+
+type TestTypesObject struct {
+	Time     time.Time        `json:"time"`
+	Duration time.Duration    `json:"duration"`
+	Raw      json.RawMessage  `json:"raw"`
+	URL      url.URL          `json:"url"`
+	Addr     *netip.Addr      `json:"addr"`
+	AddrPort netip.AddrPort   `json:"addrPort"`
+	Prefix   netip.Prefix     `json:"prefix"`
+	IP       net.IP           `json:"ip"`
+	Int      big.Int          `json:"int"`
+	Float    *big.Float       `json:"float"`
+	Rat      *big.Rat         `json:"rat"`
+	Text     TestTextObject   `json:"text"`
+	Custom   TestCustomObject `json:"custom"`
+}
+
+type TestTextObject struct {
+	a, b string
+}
+
+func (o TestTextObject) MarshalText() ([]byte, error) {
+	return []byte(o.a + "/" + o.b), nil
+}
+
+type TestCustomObject struct {
+	ID [16]byte
+}
+
+func (o TestCustomObject) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%x-%x-%x-%x-%x", o.ID[0:4], o.ID[4:6], o.ID[6:8], o.ID[8:10], o.ID[10:]))
+}
+
+type TestAllocationSpec interface {
+	isAllocationSpec()
+}
+
+type TestAllocation struct {
+	Spec TestAllocationSpec `json:"spec"`
+}
+
+type TestFleetAllocation struct {
+	Kind  string `json:"kind"`
+	Fleet string `json:"fleet"`
+}
+
+func (TestFleetAllocation) isAllocationSpec() {}
+
+type TestRoomAllocation struct {
+	Kind string `json:"kind"`
+	Room string `json:"room"`
+}
+
+func (TestRoomAllocation) isAllocationSpec() {}
+
+type TestAllocationResult struct {
+	Fleet *TestFleetAllocation
+	Room  *TestRoomAllocation
+}
+
+func (TestAllocationResult) OpenAPIDiscriminator() (string, map[string]any) {
+	return "kind", map[string]any{
+		"fleet": TestFleetAllocation{},
+		"room":  TestRoomAllocation{},
+	}
+}
+
+type TestRegion string
+
+func (TestRegion) OpenAPIEnum() []any {
+	return []any{"eu", "us", "ap"}
+}
+
+func (TestRegion) OpenAPIEnumDescriptions() []string {
+	return []string{"Europe", "United States", "Asia Pacific"}
+}
+
+func (TestRegion) OpenAPIEnumVarNames() []string {
+	return []string{"RegionEU", "RegionUS", "RegionAP"}
+}
+
+type TestRegionObject struct {
+	Region  TestRegion   `json:"region"`
+	Regions []TestRegion `json:"regions"`
+}
+
+type TestValidatedObject struct {
+	Name     string            `json:"name" validate:"required,min=1,max=64,alphanum"`
+	Code     string            `json:"code" validate:"len=4"`
+	Email    string            `json:"email" validate:"omitempty,email"`
+	ID       string            `json:"id" validate:"required,uuid"`
+	Website  string            `json:"website" validate:"url"`
+	Addr     string            `json:"addr" validate:"ip"`
+	Replicas int               `json:"replicas" validate:"gte=0,lte=100"`
+	Ratio    float64           `json:"ratio" validate:"gt=0,lt=1"`
+	Mode     string            `json:"mode" validate:"oneof=fast slow"`
+	Level    int               `json:"level" validate:"oneof=1 2 3"`
+	Tags     []string          `json:"tags" validate:"max=10,dive,min=1,max=32"`
+	Labels   map[string]string `json:"labels" validate:"dive,keys,min=1,endkeys,max=63"`
+	Ignored  string            `json:"ignored" validate:"-"`
+}
+
+type TestTaggedObject struct {
+	ID       string `json:"id" openapi:"description=The object ID, assigned by the server,format=uuid,readonly"`
+	Region   string `json:"region" openapi:"example=eu-1,required"`
+	Replicas int    `json:"replicas" openapi:"example=3"`
+	Legacy   string `json:"legacy" openapi:"deprecated,description=Overridden by Docs"`
+	Secret   string `json:"secret" openapi:"writeonly,format=password"`
+}
+
+func (TestTaggedObject) Docs() map[string]string {
+	return map[string]string{
+		"legacy": "Legacy is no longer used.",
+	}
+}
+
+type TestObjectMeta struct {
+	Name        string            `json:"name"`
+	Environment string            `json:"environment"`
+	Labels      map[string]string `json:"labels"`
+}
+
+func (TestObjectMeta) Docs() map[string]string {
+	return map[string]string{
+		"name":        "Name is the unique name of the object.",
+		"environment": "Environment is the environment of the object.",
+		"labels":      "Labels are the object labels.",
+	}
+}
+
+func (TestObjectMeta) Attributes() map[string]string {
+	return map[string]string{
+		"name":        "required",
+		"environment": "required",
+	}
+}
+
+type TestFleet struct {
+	TestObjectMeta
+
+	Replicas int `json:"replicas"`
+}
+
+func (TestFleet) Docs() map[string]string {
+	return map[string]string{
+		"labels":   "Labels are the fleet labels.",
+		"replicas": "Replicas is the number of game servers.",
+	}
+}
+
+type TestAttributedObject struct {
+	ID       string  `json:"id"`
+	Password string  `json:"password"`
+	Comment  *string `json:"comment"`
+	Legacy   string  `json:"legacy"`
+}
+
+func (TestAttributedObject) Attributes() map[string][]string {
+	return map[string][]string{
+		"id":       {"required", "readonly"},
+		"password": {"required", "writeonly", "sensitive"},
+		"comment":  {"nullable"},
+		"legacy":   {"deprecated"},
+	}
+}
+
+type TestInferredObject struct {
+	Name     string            `json:"name"`
+	Comment  string            `json:"comment,omitempty"`
+	Parent   *string           `json:"parent"`
+	Owner    *string           `json:"owner,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Items    []string          `json:"items"`
+	Status   string            `json:"status"`
+	Replicas int               `json:"replicas,omitzero"`
+	Note     string            `json:"note"`
+	Region   string            `json:"region" openapi:"optional"`
+}
+
+func (TestInferredObject) Attributes() map[string][]string {
+	return map[string][]string{
+		"status": {"readonly"},
+		"note":   {"optional"},
+	}
+}
+
+type TestInferredLegacyObject struct {
+	Name string `json:"name"`
+	Note string `json:"note"`
+}
+
+func (TestInferredLegacyObject) Attributes() map[string]string {
+	return map[string]string{
+		"note": "optional",
+	}
+}
+
+type TestTree struct {
+	Root TestTreeNode `json:"root"`
+}
+
+func (TestTree) Docs() map[string]string {
+	return map[string]string{
+		"root": "The root node of the tree.",
+	}
+}
+
+type TestTreeValue any
+
+type TestTreeNode struct {
+	Name     string         `json:"name"`
+	Value    TestTreeValue  `json:"value"`
+	Children []TestTreeNode `json:"children"`
+	Edges    []TestTreeEdge `json:"edges"`
+}
+
+type TestTreeEdge struct {
+	Target *TestTreeNode `json:"target"`
+}
+
+type TestDescribedObject struct {
+	Name     string         `json:"name"`
+	Replicas int            `json:"replicas" openapi:"default=1"`
+	Policy   TestPolicy     `json:"policy"`
+	Ports    map[string]int `json:"ports"`
+}
+
+func (TestDescribedObject) OpenAPITitle() string {
+	return "Described Object"
+}
+
+func (TestDescribedObject) OpenAPIDescription() string {
+	return "TestDescribedObject is an object with schema metadata."
+}
+
+func (TestDescribedObject) OpenAPIExample() any {
+	return map[string]any{"name": "my-object", "replicas": 2}
+}
+
+func (TestDescribedObject) Docs() map[string]string {
+	return map[string]string{
+		"policy": "The scaling policy of the object.",
+	}
+}
+
+func (TestDescribedObject) Defaults() map[string]any {
+	return map[string]any{
+		"ports": map[string]int{"game": 7777},
+	}
+}
+
+func (TestDescribedObject) Examples() map[string]any {
+	return map[string]any{
+		"name": "my-object",
+	}
+}
+
+type TestPolicy struct {
+	Kind string `json:"kind"`
+}
+
+func (TestPolicy) OpenAPIDescription() string {
+	return "TestPolicy is a scaling policy."
+}
+
+func (TestPolicy) OpenAPIDefault() any {
+	return map[string]any{"kind": "buffer"}
+}
+
+type TestExtendedObject struct {
+	Name string `json:"name"`
+}
+
+func (TestExtendedObject) OpenAPIExtensions() map[string]any {
+	return map[string]any{
+		"x-codegen-model": "Item",
+	}
+}
+
+type TestPatchable struct {
+	ID     string            `json:"id" openapi:"readonly"`
+	Name   string            `json:"name" openapi:"required"`
+	Spec   TestPatchableSpec `json:"spec"`
+	Labels map[string]string `json:"labels"`
+	Ports  []int             `json:"ports"`
+}
+
+type TestPatchableSpec struct {
+	Image    string `json:"image" openapi:"required"`
+	Replicas int    `json:"replicas"`
+	Status   string `json:"status" openapi:"readonly"`
+}
+
+type TestAccount struct {
+	ID       string             `json:"id" openapi:"readonly,required"`
+	Name     string             `json:"name" openapi:"required"`
+	Password string             `json:"password" openapi:"writeonly,required"`
+	Profile  TestAccountProfile `json:"profile"`
+}
+
+type TestAccountProfile struct {
+	Email     string `json:"email"`
+	CreatedAt string `json:"createdAt" openapi:"readonly"`
+}
+
+type TestAccountList struct {
+	Items []TestAccount `json:"items"`
+}
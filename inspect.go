@@ -0,0 +1,60 @@
+package openapi
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// OperationSource is the source of the operation of a route.
+type OperationSource int
+
+// Operation sources, combined if the operation is merged from both.
+const (
+	// SourceMiddleware is an operation built with Build, used as a middleware.
+	SourceMiddleware OperationSource = 1 << iota
+	// SourceHandler is an operation built with BuildHandler, wrapping the handler.
+	SourceHandler
+)
+
+// RouteInfo describes a documented route.
+type RouteInfo struct {
+	// Method is the HTTP method of the route.
+	Method string
+
+	// Path is the path of the route, as documented in the spec.
+	Path string
+
+	// Operation is the operation of the route, merged from all its sources.
+	Operation Operation
+
+	// Source is the source of the operation.
+	Source OperationSource
+}
+
+// Inspect returns the routes of the given chi router documented in the
+// spec built with the same configuration.
+func Inspect(r chi.Routes, cfg SpecConfig) ([]RouteInfo, error) {
+	return InspectFrom(ChiWalker(r), cfg)
+}
+
+// InspectFrom returns the routes of the given walker documented in the
+// spec built with the same configuration.
+func InspectFrom(w Walker, cfg SpecConfig) ([]RouteInfo, error) {
+	var routes []RouteInfo
+	err := walkOperations(w, registryOrDefault(cfg.Registry), func(method, route string, op Operation, src OperationSource) error {
+		if op.id == "" {
+			return nil
+		}
+
+		routes = append(routes, RouteInfo{
+			Method:    method,
+			Path:      normalizePath(route, cfg.StripPrefixes),
+			Operation: op,
+			Source:    src,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
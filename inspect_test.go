@@ -0,0 +1,75 @@
+package openapi_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gamefabric/openapi"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	mux := chi.NewMux()
+	mux.Use(openapi.Op().Produces("application/json").Build())
+
+	mux.Route("/internal/api", func(r chi.Router) {
+		r.With(openapi.Op().
+			ID("get-item").
+			Doc("Gets an item.").
+			Param(openapi.PathParameter("name", "the item name")).
+			Returns(http.StatusOK, "OK", &TestSimpleObject{}, openapi.WithResponseHeader("ETag")).
+			Build()).Get("/items/{name}", func(rw http.ResponseWriter, req *http.Request) {})
+	})
+	mux.Post("/internal/handler", openapi.Op().
+		ID("post-handler").
+		Reads(&TestSimpleObject{}, openapi.WithOptionalRequestBody()).
+		BuildHandler()(func(rw http.ResponseWriter, req *http.Request) {}))
+	mux.Get("/undocumented", func(rw http.ResponseWriter, req *http.Request) {})
+
+	routes, err := openapi.Inspect(mux, openapi.SpecConfig{StripPrefixes: []string{"/internal"}})
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+
+	get := routes[0]
+	assert.Equal(t, http.MethodGet, get.Method)
+	assert.Equal(t, "/api/items/{name}", get.Path)
+	assert.Equal(t, openapi.SourceMiddleware, get.Source)
+	assert.Equal(t, "get-item", get.Operation.ID())
+	assert.Equal(t, "Gets an item.", get.Operation.Doc())
+	assert.Equal(t, []string{"application/json"}, get.Operation.Produces())
+	require.Len(t, get.Operation.Params(), 1)
+	assert.Equal(t, "path", get.Operation.Params()[0].In())
+	assert.Equal(t, "name", get.Operation.Params()[0].Name())
+	assert.True(t, get.Operation.Params()[0].Required())
+	require.Len(t, get.Operation.Responses(), 1)
+	assert.Equal(t, []string{"ETag"}, get.Operation.Responses()[0].Headers())
+	assert.IsType(t, &TestSimpleObject{}, get.Operation.Responses()[0].Writes())
+
+	post := routes[1]
+	assert.Equal(t, http.MethodPost, post.Method)
+	assert.Equal(t, "/handler", post.Path)
+	assert.Equal(t, openapi.SourceMiddleware|openapi.SourceHandler, post.Source)
+	assert.Equal(t, "post-handler", post.Operation.ID())
+	require.NotNil(t, post.Operation.Reads())
+	assert.True(t, post.Operation.Reads().Optional())
+}
+
+func TestInspectMatchesSpecIDs(t *testing.T) {
+	mux := chi.NewMux()
+	mux.With(openapi.Op().ID("health").Build()).Handle("/health", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+
+	doc, err := openapi.BuildSpec(mux, openapi.SpecConfig{})
+	require.NoError(t, err)
+	routes, err := openapi.Inspect(mux, openapi.SpecConfig{})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, routes)
+	for _, r := range routes {
+		op := doc.Paths.Find(r.Path).GetOperation(r.Method)
+		require.NotNil(t, op, r.Method)
+		assert.Equal(t, op.OperationID, r.Operation.ID())
+		assert.NotEqual(t, "health", r.Operation.ID())
+	}
+}
@@ -1,8 +1,10 @@
 package openapi
 
 import (
+	"maps"
 	"net/http"
 	"reflect"
+	"slices"
 	"sync"
 
 	kin "github.com/getkin/kin-openapi/openapi3"
@@ -16,48 +18,119 @@ type Parameter struct {
 	required    bool
 	typ         string
 	dataType    any
+	extensions  map[string]any
+	component   string
+}
+
+// In returns the location of the parameter, being "path", "query" or "header".
+func (p Parameter) In() string {
+	return p.in
+}
+
+// Name returns the name of the parameter.
+func (p Parameter) Name() string {
+	return p.name
+}
+
+// Description returns the description of the parameter.
+func (p Parameter) Description() string {
+	return p.description
+}
+
+// Required returns true if the parameter is required.
+func (p Parameter) Required() bool {
+	return p.required
+}
+
+// Type returns the explicit schema type of the parameter, if any.
+func (p Parameter) Type() string {
+	return p.typ
+}
+
+// DataType returns the value the schema of the parameter is derived from, if any.
+func (p Parameter) DataType() any {
+	return p.dataType
+}
+
+// Extensions returns the vendor extensions of the parameter.
+func (p Parameter) Extensions() map[string]any {
+	return maps.Clone(p.extensions)
+}
+
+// Component returns the component name of the parameter, if any.
+func (p Parameter) Component() string {
+	return p.component
+}
+
+// ParameterOptFunc is an option function for configuring the parameter.
+type ParameterOptFunc func(*Parameter)
+
+// WithParameterExtension sets the vendor extension on the parameter.
+// The key must start with "x-".
+func WithParameterExtension(key string, value any) ParameterOptFunc {
+	return func(param *Parameter) {
+		if param.extensions == nil {
+			param.extensions = map[string]any{}
+		}
+		param.extensions[key] = value
+	}
 }
 
 // PathParameter returns a path parameter with the given name and description.
-func PathParameter(name, description string) Parameter {
-	return Parameter{
+func PathParameter(name, description string, opts ...ParameterOptFunc) Parameter {
+	return newParameter(Parameter{
 		in:          kin.ParameterInPath,
 		name:        name,
 		description: description,
 		required:    true,
 		dataType:    "",
-	}
+	}, opts)
 }
 
 // QueryParameter returns a query parameter where the type will be resolved.
-func QueryParameter(name, description string, typ any) Parameter {
-	return Parameter{
+func QueryParameter(name, description string, typ any, opts ...ParameterOptFunc) Parameter {
+	return newParameter(Parameter{
 		in:          kin.ParameterInQuery,
 		name:        name,
 		description: description,
 		dataType:    typ,
-	}
+	}, opts)
 }
 
 // QueryParameterWithType returns a query parameter with the given type.
-func QueryParameterWithType(name, description, typ string) Parameter {
-	return Parameter{
+func QueryParameterWithType(name, description, typ string, opts ...ParameterOptFunc) Parameter {
+	return newParameter(Parameter{
 		in:          kin.ParameterInQuery,
 		name:        name,
 		description: description,
 		typ:         typ,
-	}
+	}, opts)
 }
 
 // HeaderParameter returns a header parameter with the given type.
-func HeaderParameter(name, description string) Parameter {
-	return Parameter{
+func HeaderParameter(name, description string, opts ...ParameterOptFunc) Parameter {
+	return newParameter(Parameter{
 		in:          kin.ParameterInHeader,
 		name:        name,
 		description: description,
+	}, opts)
+}
+
+// WithParameterComponent registers the parameter as a reusable component
+// under the given name, referencing it from the operations using it.
+func WithParameterComponent(name string) ParameterOptFunc {
+	return func(param *Parameter) {
+		param.component = name
 	}
 }
 
+func newParameter(param Parameter, opts []ParameterOptFunc) Parameter {
+	for _, opt := range opts {
+		opt(&param)
+	}
+	return param
+}
+
 // Response documents a request response.
 type Response struct {
 	code        int
@@ -65,6 +138,44 @@ type Response struct {
 	writes      any
 	headers     []string
 	mediaTypes  []string
+	extensions  map[string]any
+	component   string
+}
+
+// Code returns the status code of the response.
+func (r Response) Code() int {
+	return r.code
+}
+
+// Description returns the description of the response.
+func (r Response) Description() string {
+	return r.description
+}
+
+// Writes returns the value the schema of the response is derived from, if any.
+func (r Response) Writes() any {
+	return r.writes
+}
+
+// Headers returns the headers of the response.
+func (r Response) Headers() []string {
+	return slices.Clone(r.headers)
+}
+
+// MediaTypes returns the media types of the response,
+// overriding those produced by the operation.
+func (r Response) MediaTypes() []string {
+	return slices.Clone(r.mediaTypes)
+}
+
+// Extensions returns the vendor extensions of the response.
+func (r Response) Extensions() map[string]any {
+	return maps.Clone(r.extensions)
+}
+
+// Component returns the component name of the response, if any.
+func (r Response) Component() string {
+	return r.component
 }
 
 // ResponseOptFunc is an option function for configuration the response.
@@ -84,15 +195,136 @@ func WithMediaTypes(mediaTypes ...string) ResponseOptFunc {
 	}
 }
 
+// WithResponseExtension sets the vendor extension on the response.
+// The key must start with "x-".
+func WithResponseExtension(key string, value any) ResponseOptFunc {
+	return func(resp *Response) {
+		if resp.extensions == nil {
+			resp.extensions = map[string]any{}
+		}
+		resp.extensions[key] = value
+	}
+}
+
+// WithResponseComponent registers the response as a reusable component
+// under the given name, referencing it from the operations using it.
+func WithResponseComponent(name string) ResponseOptFunc {
+	return func(resp *Response) {
+		resp.component = name
+	}
+}
+
+// RequestBody documents a request body.
+type RequestBody struct {
+	reads       any
+	optional    bool
+	description string
+	mediaTypes  map[string]any
+	examples    map[string]any
+	encodings   map[string]string
+	component   string
+}
+
+// Reads returns the value the schema of the request body is derived from, if any.
+func (b RequestBody) Reads() any {
+	return b.reads
+}
+
+// Optional returns true if the request body is optional.
+func (b RequestBody) Optional() bool {
+	return b.optional
+}
+
+// Description returns the description of the request body.
+func (b RequestBody) Description() string {
+	return b.description
+}
+
+// MediaTypes returns the values the schemas of the request body
+// are derived from for specific media types.
+func (b RequestBody) MediaTypes() map[string]any {
+	return maps.Clone(b.mediaTypes)
+}
+
+// Component returns the component name of the request body, if any.
+func (b RequestBody) Component() string {
+	return b.component
+}
+
+// RequestBodyOptFunc is an option function for configuring the request body.
+type RequestBodyOptFunc func(*RequestBody)
+
+// WithOptionalRequestBody marks the request body as optional.
+func WithOptionalRequestBody() RequestBodyOptFunc {
+	return func(body *RequestBody) {
+		body.optional = true
+	}
+}
+
+// WithRequestBodyDescription sets the description of the request body.
+func WithRequestBodyDescription(desc string) RequestBodyOptFunc {
+	return func(body *RequestBody) {
+		body.description = desc
+	}
+}
+
+// WithRequestBodyMediaType sets the type read for the given media type,
+// instead of the request body type. The media type does not need to be
+// consumed by the operation. A nil object documents the media type
+// without a schema.
+func WithRequestBodyMediaType(mediaType string, obj any) RequestBodyOptFunc {
+	return func(body *RequestBody) {
+		if body.mediaTypes == nil {
+			body.mediaTypes = map[string]any{}
+		}
+		body.mediaTypes[mediaType] = obj
+	}
+}
+
+// WithRequestBodyExample adds a named example of the request body type.
+// The example is added to all media types reading the request body type.
+func WithRequestBodyExample(name string, value any) RequestBodyOptFunc {
+	return func(body *RequestBody) {
+		if body.examples == nil {
+			body.examples = map[string]any{}
+		}
+		body.examples[name] = value
+	}
+}
+
+// WithRequestBodyEncoding sets the content type of the given property
+// for the multipart and form url encoded media types.
+func WithRequestBodyEncoding(property, contentType string) RequestBodyOptFunc {
+	return func(body *RequestBody) {
+		if body.encodings == nil {
+			body.encodings = map[string]string{}
+		}
+		body.encodings[property] = contentType
+	}
+}
+
+// WithRequestBodyComponent registers the request body as a reusable component
+// under the given name, referencing it from the operations using it.
+func WithRequestBodyComponent(name string) RequestBodyOptFunc {
+	return func(body *RequestBody) {
+		body.component = name
+	}
+}
+
 const (
-	secTypeBearer = "bearer"
-	secTypeBasic  = "basic"
-	secTypeAPIKey = "apiKey"
+	secTypeBearer        = "bearer"
+	secTypeBasic         = "basic"
+	secTypeAPIKey        = "apiKey"
+	secTypeOAuth2        = "oauth2"
+	secTypeOpenIDConnect = "openIdConnect"
+	secTypeMutualTLS     = "mutualTLS"
 )
 
 // Security represents a security configuration.
 type Security struct {
-	// Type is the security type, valid values are "bearer", "auth" and "apiKey".
+	// Type is the security type, valid values are "bearer", "basic", "apiKey",
+	// "oauth2", "openIdConnect" and "mutualTLS". Mutual TLS can only be
+	// enforced with RequireAuth, as it cannot be documented in OpenAPI 3.0.
 	Type string
 
 	// BearerFormat is a hint to the client to identify how the bearer token is formatted.
@@ -105,6 +337,36 @@ type Security struct {
 
 	// APIKeyIn is required for type "apiKey", valid values are "query", "header" or "cookie".
 	APIKeyIn string
+
+	// Flows is required for type "oauth2" and contains the supported flows.
+	Flows *OAuthFlows
+
+	// OpenIDConnectURL is required for type "openIdConnect" and contains
+	// the URL of the OpenID Connect discovery document.
+	OpenIDConnectURL string
+}
+
+// OAuthFlows contains the supported OAuth2 flows.
+type OAuthFlows struct {
+	Implicit          *OAuthFlow
+	Password          *OAuthFlow
+	ClientCredentials *OAuthFlow
+	AuthorizationCode *OAuthFlow
+}
+
+// OAuthFlow configures an OAuth2 flow.
+type OAuthFlow struct {
+	// AuthorizationURL is required for the implicit and authorization code flows.
+	AuthorizationURL string
+
+	// TokenURL is required for the password, client credentials and authorization code flows.
+	TokenURL string
+
+	// RefreshURL is the URL to obtain refresh tokens from.
+	RefreshURL string
+
+	// Scopes maps the available scopes to their descriptions.
+	Scopes map[string]string
 }
 
 // SecurityNone means no security is required for this endpoint.
@@ -121,22 +383,85 @@ var (
 	SecurityBasic = Security{
 		Type: secTypeBasic,
 	}
+	SecurityMutualTLS = Security{
+		Type: secTypeMutualTLS,
+	}
 )
 
+// SecurityRequirement requires a named security scheme, with the
+// given scopes for "oauth2" and "openIdConnect" schemes.
+type SecurityRequirement struct {
+	Name     string
+	Security Security
+	Scopes   []string
+}
+
+// Requirement returns a requirement of the named security scheme.
+func Requirement(name string, sec Security, scopes ...string) SecurityRequirement {
+	return SecurityRequirement{Name: name, Security: sec, Scopes: scopes}
+}
+
+// securityGroup is a group of security requirements that are all required.
+// An empty group allows anonymous access.
+type securityGroup []SecurityRequirement
+
+func (g securityGroup) equal(other securityGroup) bool {
+	return slices.EqualFunc(g, other, func(a, b SecurityRequirement) bool {
+		return a.Name == b.Name && a.Security == b.Security && slices.Equal(a.Scopes, b.Scopes)
+	})
+}
+
+// securityGroups returns the security groups of the given requirements.
+func securityGroups(reqs [][]SecurityRequirement) []securityGroup {
+	groups := make([]securityGroup, 0, len(reqs))
+	for _, group := range reqs {
+		groups = appendSecurityGroups(groups, group)
+	}
+	return groups
+}
+
+// appendSecurityGroups appends the groups not yet contained in security.
+func appendSecurityGroups(security []securityGroup, groups ...securityGroup) []securityGroup {
+	for _, group := range groups {
+		if slices.ContainsFunc(security, group.equal) {
+			continue
+		}
+		security = append(security, group)
+	}
+	return security
+}
+
+// Server describes a server an operation is served from.
+type Server struct {
+	// URL is the URL of the server.
+	URL string
+
+	// Description describes the server.
+	Description string
+}
+
 // Operation documents a request.
 type Operation struct {
-	id       string
-	tags     []string
-	doc      string
-	params   []Parameter
-	consumes []string
-	reads    any
-	produces []string
-	returns  []Response
-	security map[string]Security
+	id         string
+	tags       []string
+	doc        string
+	desc       string
+	extDocs    *kin.ExternalDocs
+	servers    []Server
+	params     []Parameter
+	consumes   []string
+	reads      *RequestBody
+	produces   []string
+	returns    []Response
+	security   []securityGroup
+	public     bool
+	extensions map[string]any
 }
 
 // Merge merges the operation with the given operation.
+//
+// Values and servers set on the given operation replace those of the operation,
+// lists are appended to and maps are merged.
 func (o Operation) Merge(newOp Operation) Operation {
 	if newOp.id != "" {
 		o.id = newOp.id
@@ -144,6 +469,15 @@ func (o Operation) Merge(newOp Operation) Operation {
 	if newOp.doc != "" {
 		o.doc = newOp.doc
 	}
+	if newOp.desc != "" {
+		o.desc = newOp.desc
+	}
+	if newOp.extDocs != nil {
+		o.extDocs = newOp.extDocs
+	}
+	if len(newOp.servers) > 0 {
+		o.servers = newOp.servers
+	}
 	if len(newOp.tags) > 0 {
 		o.tags = append([]string{}, o.tags...)
 		o.tags = append(o.tags, newOp.tags...)
@@ -167,17 +501,127 @@ func (o Operation) Merge(newOp Operation) Operation {
 		o.returns = append([]Response{}, o.returns...)
 		o.returns = append(o.returns, newOp.returns...)
 	}
-	if len(newOp.security) != 0 {
-		if o.security == nil {
-			o.security = map[string]Security{}
-		}
-		for k, v := range newOp.security {
-			o.security[k] = v
-		}
+	o.mergeSecurity(newOp)
+	if len(newOp.extensions) != 0 {
+		o.extensions = mergeExtensions(o.extensions, newOp.extensions)
 	}
 	return o
 }
 
+// mergeSecurity merges the security of the given operation, a public
+// operation clearing the requirements and requirements clearing public.
+func (o *Operation) mergeSecurity(newOp Operation) {
+	if newOp.public {
+		o.public = true
+		o.security = nil
+	}
+	if len(newOp.security) != 0 {
+		o.public = false
+		o.security = appendSecurityGroups(slices.Clone(o.security), newOp.security...)
+	}
+}
+
+// mergeExtensions returns a new map of the given extensions,
+// the new extensions overriding the existing ones.
+func mergeExtensions(exts, newExts map[string]any) map[string]any {
+	merged := make(map[string]any, len(exts)+len(newExts))
+	for k, v := range exts {
+		merged[k] = v
+	}
+	for k, v := range newExts {
+		merged[k] = v
+	}
+	return merged
+}
+
+// ID returns the operation ID.
+func (o Operation) ID() string {
+	return o.id
+}
+
+// Tags returns the tags of the operation.
+func (o Operation) Tags() []string {
+	return slices.Clone(o.tags)
+}
+
+// Doc returns the summary of the operation.
+func (o Operation) Doc() string {
+	return o.doc
+}
+
+// Description returns the description of the operation.
+func (o Operation) Description() string {
+	return o.desc
+}
+
+// ExternalDocs returns the URL and description of the external
+// documentation of the operation.
+func (o Operation) ExternalDocs() (url, description string) {
+	if o.extDocs == nil {
+		return "", ""
+	}
+	return o.extDocs.URL, o.extDocs.Description
+}
+
+// Servers returns the servers of the operation.
+func (o Operation) Servers() []Server {
+	return slices.Clone(o.servers)
+}
+
+// Params returns the parameters of the operation.
+func (o Operation) Params() []Parameter {
+	return slices.Clone(o.params)
+}
+
+// Consumes returns the media types consumed by the operation.
+func (o Operation) Consumes() []string {
+	return slices.Clone(o.consumes)
+}
+
+// Reads returns the request body of the operation, or nil if it has none.
+func (o Operation) Reads() *RequestBody {
+	if o.reads == nil {
+		return nil
+	}
+	body := *o.reads
+	return &body
+}
+
+// Produces returns the media types produced by the operation.
+func (o Operation) Produces() []string {
+	return slices.Clone(o.produces)
+}
+
+// Security returns the security requirement groups of the operation.
+// Each group lists the requirements that are all required, any of the
+// groups being sufficient. An empty group allows anonymous access.
+func (o Operation) Security() [][]SecurityRequirement {
+	if len(o.security) == 0 {
+		return nil
+	}
+
+	groups := make([][]SecurityRequirement, 0, len(o.security))
+	for _, group := range o.security {
+		groups = append(groups, slices.Clone(group))
+	}
+	return groups
+}
+
+// Public returns true if the operation requires no authentication.
+func (o Operation) Public() bool {
+	return o.public
+}
+
+// Responses returns the documented responses of the operation.
+func (o Operation) Responses() []Response {
+	return slices.Clone(o.returns)
+}
+
+// Extensions returns the vendor extensions of the operation.
+func (o Operation) Extensions() map[string]any {
+	return maps.Clone(o.extensions)
+}
+
 // OpBuilder builds an operation. An operation describes a request route.
 type OpBuilder struct {
 	op *Operation
@@ -200,6 +644,29 @@ func (o *OpBuilder) Doc(doc string) *OpBuilder {
 	return o
 }
 
+// Description sets the operation description. CommonMark syntax can
+// be used for rich text representation.
+func (o *OpBuilder) Description(desc string) *OpBuilder {
+	o.op.desc = desc
+	return o
+}
+
+// ExternalDocs sets the external documentation of the operation.
+func (o *OpBuilder) ExternalDocs(url, desc string) *OpBuilder {
+	o.op.extDocs = &kin.ExternalDocs{
+		URL:         url,
+		Description: desc,
+	}
+	return o
+}
+
+// Servers sets the servers the operation is served from,
+// overriding the servers of the document.
+func (o *OpBuilder) Servers(servers ...Server) *OpBuilder {
+	o.op.servers = servers
+	return o
+}
+
 // Tag appends the given tag to the operation.
 func (o *OpBuilder) Tag(tag string) *OpBuilder {
 	o.op.tags = append(o.op.tags, tag)
@@ -225,8 +692,22 @@ func (o *OpBuilder) Consumes(mediaTypes ...string) *OpBuilder {
 }
 
 // Reads sets the request body type on the operation.
-func (o *OpBuilder) Reads(obj any) *OpBuilder {
-	o.op.reads = obj
+// A nil object without options leaves the request body unset,
+// to be set by another operation the operation is merged with.
+func (o *OpBuilder) Reads(obj any, opts ...RequestBodyOptFunc) *OpBuilder {
+	if obj == nil && len(opts) == 0 {
+		return o
+	}
+
+	body := &RequestBody{
+		reads: obj,
+	}
+
+	for _, opt := range opts {
+		opt(body)
+	}
+
+	o.op.reads = body
 	return o
 }
 
@@ -254,14 +735,60 @@ func (o *OpBuilder) Returns(code int, description string, obj any, opts ...Respo
 
 // RequiresAuth requires authentication for this endpoint.
 //
-// The supported authentication types are "bearer", "basic" and "apiKey".
-// The same name requires the same Security object, as all security schemes are registered under its name. The behavior for not
-// following this directive is undetermined.
-func (o *OpBuilder) RequiresAuth(name string, sec Security) *OpBuilder {
-	if o.op.security == nil {
-		o.op.security = map[string]Security{}
-	}
-	o.op.security[name] = sec
+// The supported authentication types are "bearer", "basic", "apiKey",
+// "oauth2", "openIdConnect" and "mutualTLS", building the spec fails
+// for "mutualTLS" as it requires OpenAPI 3.1. The scopes required for
+// "oauth2" and "openIdConnect" can be given, and are listed in the
+// security requirement of the endpoint.
+// The same name requires the same Security object, as all security schemes are registered under its name.
+// Building the spec fails if a name is used with different Security objects.
+//
+// Each call adds an alternative way to authenticate, any of which is
+// sufficient. Requiring SecurityNone allows anonymous access.
+func (o *OpBuilder) RequiresAuth(name string, sec Security, scopes ...string) *OpBuilder {
+	if sec == SecurityNone {
+		return o.AllowsAnonymous()
+	}
+	return o.RequiresAllAuth(Requirement(name, sec, scopes...))
+}
+
+// RequiresAllAuth requires authentication with all the given security
+// schemes together for this endpoint.
+//
+// Each call adds an alternative way to authenticate, any of which is
+// sufficient.
+func (o *OpBuilder) RequiresAllAuth(reqs ...SecurityRequirement) *OpBuilder {
+	if len(reqs) == 0 {
+		return o
+	}
+	o.op.public = false
+	o.op.security = appendSecurityGroups(o.op.security, securityGroup(reqs))
+	return o
+}
+
+// AllowsAnonymous allows anonymous access to this endpoint,
+// as an alternative to the required authentication.
+func (o *OpBuilder) AllowsAnonymous() *OpBuilder {
+	o.op.public = false
+	o.op.security = appendSecurityGroups(o.op.security, securityGroup{})
+	return o
+}
+
+// Public exempts this endpoint from the security required by the spec
+// configuration, documenting that it requires no authentication.
+func (o *OpBuilder) Public() *OpBuilder {
+	o.op.public = true
+	o.op.security = nil
+	return o
+}
+
+// Extension sets the vendor extension on the operation.
+// The key must start with "x-".
+func (o *OpBuilder) Extension(key string, value any) *OpBuilder {
+	if o.op.extensions == nil {
+		o.op.extensions = map[string]any{}
+	}
+	o.op.extensions[key] = value
 	return o
 }
 
@@ -279,30 +806,53 @@ func (o *OpBuilder) Build() func(http.Handler) http.Handler {
 
 // BuildHandler builds a wrapper handler that contains an Operation.
 //
-// The operation is registered globally with the operation registrar
+// The operation is registered globally with the default registry
 // as there is no other way to retrieve the operation from a handler func.
+// The returned handler wraps the given handler, see BuildHandlerIn.
 func (o *OpBuilder) BuildHandler() func(http.HandlerFunc) http.HandlerFunc {
+	return o.BuildHandlerIn(defaultRegistry)
+}
+
+// BuildHandlerIn builds a wrapper handler that contains an Operation,
+// registering the operation with the given registry.
+//
+// The returned handler is a new handler func wrapping the given one, and
+// not the given handler itself. Each wrapper handler is registered
+// separately, so the same handler func can be documented with different
+// operations on different routes. Registrations are kept until they are
+// removed with Registry.Delete or Registry.Reset.
+func (o *OpBuilder) BuildHandlerIn(reg *Registry) func(http.HandlerFunc) http.HandlerFunc {
+	reg = registryOrDefault(reg)
 	return func(next http.HandlerFunc) http.HandlerFunc {
-		opReg.Register(next, *o.op)
+		h := func(rw http.ResponseWriter, req *http.Request) {
+			next(rw, req)
+		}
+		reg.register(h, *o.op)
 
-		return next
+		return h
 	}
 }
 
+// opHandler is the handler probing middlewares for their operation.
+// The middlewares built with Build return an opHandler carrying their
+// operation when given an opHandler.
 type opHandler struct {
 	Op Operation
 }
 
 func (o opHandler) ServeHTTP(_ http.ResponseWriter, _ *http.Request) {}
 
-var opReg = registrar{}
+// defaultRegistry is the registry of BuildHandler.
+var defaultRegistry = &Registry{}
 
-type registrar struct {
+// Registry holds the operations of the handlers built with BuildHandlerIn.
+// The zero value is ready to use.
+type Registry struct {
 	mu  sync.Mutex
 	ops map[reflect.Value]Operation
 }
 
-func (r *registrar) Register(i any, op Operation) {
+func (r *Registry) register(h http.HandlerFunc, op Operation) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -310,15 +860,43 @@ func (r *registrar) Register(i any, op Operation) {
 		r.ops = map[reflect.Value]Operation{}
 	}
 
-	v := reflect.ValueOf(i)
+	v := reflect.ValueOf(h)
 	r.ops[v] = op
 }
 
-func (r *registrar) Op(i any) (Operation, bool) {
+// Delete removes the operation of the given handler, as returned by BuildHandlerIn.
+func (r *Registry) Delete(h http.HandlerFunc) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	v := reflect.ValueOf(i)
+	delete(r.ops, reflect.ValueOf(h))
+}
+
+// Reset removes the operations of all handlers.
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ops = nil
+}
+
+func (r *Registry) op(h http.Handler) (Operation, bool) {
+	hf, ok := h.(http.HandlerFunc)
+	if !ok {
+		return Operation{}, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	v := reflect.ValueOf(hf)
 	op, ok := r.ops[v]
 	return op, ok
 }
+
+func registryOrDefault(reg *Registry) *Registry {
+	if reg == nil {
+		return defaultRegistry
+	}
+	return reg
+}
@@ -0,0 +1,57 @@
+package openapi_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gamefabric/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func operation(t *testing.T, b *openapi.OpBuilder) openapi.Operation {
+	t.Helper()
+
+	op, src := openapi.OperationOf(nil, http.NotFoundHandler(), b.Build())
+	require.Equal(t, openapi.SourceMiddleware, src)
+	return op
+}
+
+func TestOperationMergeReads(t *testing.T) {
+	mw := operation(t, openapi.Op().Reads(&TestSimpleObject{}))
+
+	op := mw.Merge(operation(t, openapi.Op().ID("handler").Reads(nil)))
+	require.NotNil(t, op.Reads())
+	assert.Equal(t, &TestSimpleObject{}, op.Reads().Reads())
+
+	op = mw.Merge(operation(t, openapi.Op().ID("handler").Reads(nil, openapi.WithOptionalRequestBody())))
+	require.NotNil(t, op.Reads())
+	assert.Nil(t, op.Reads().Reads())
+	assert.True(t, op.Reads().Optional())
+}
+
+func TestRegistry(t *testing.T) {
+	handler := func(rw http.ResponseWriter, req *http.Request) {}
+
+	reg := &openapi.Registry{}
+	items := openapi.Op().ID("list-items").BuildHandlerIn(reg)(handler)
+	others := openapi.Op().ID("list-others").BuildHandlerIn(reg)(handler)
+
+	op, src := openapi.OperationOf(reg, items)
+	assert.Equal(t, openapi.SourceHandler, src)
+	assert.Equal(t, "list-items", op.ID())
+	op, _ = openapi.OperationOf(reg, others)
+	assert.Equal(t, "list-others", op.ID())
+	_, src = openapi.OperationOf(reg, http.HandlerFunc(handler))
+	assert.Zero(t, src)
+
+	reg.Delete(items)
+	_, src = openapi.OperationOf(reg, items)
+	assert.Zero(t, src)
+	_, src = openapi.OperationOf(reg, others)
+	assert.Equal(t, openapi.SourceHandler, src)
+
+	reg.Reset()
+	_, src = openapi.OperationOf(reg, others)
+	assert.Zero(t, src)
+}
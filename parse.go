@@ -46,6 +46,11 @@ func ParseParams(obj any, tag string) []Parameter {
 			desc = docable.Docs()[tagName]
 		}
 
+		if _, ok := reflect.New(f.Type).Elem().Interface().(enumerable); ok {
+			params = append(params, QueryParameter(tagName, desc, reflect.New(f.Type).Elem().Interface()))
+			continue
+		}
+
 		params = append(params, QueryParameterWithType(tagName, desc, typeToJSON(f.Type.String())))
 	}
 	return params
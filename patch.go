@@ -0,0 +1,201 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	kin "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Patch media types.
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// patch is a request body derived from a type for a patch media type.
+type patch interface {
+	mediaType() string
+}
+
+type mergePatch struct {
+	obj any
+}
+
+func (mergePatch) mediaType() string { return MediaTypeMergePatch }
+
+type jsonPatch struct{}
+
+func (jsonPatch) mediaType() string { return MediaTypeJSONPatch }
+
+// MergePatchOf returns a JSON Merge Patch (RFC 7396) of the given type,
+// to be used as a request body.
+//
+// Its schema is derived from the schema of the type, with all properties
+// being optional and nullable, and read only properties removed. Composed
+// schemas, such as embedded structs documented as "allOf" their components
+// or polymorphic types, refer to the merge patch schemas of their components.
+// The request body is read as "application/merge-patch+json".
+func MergePatchOf(obj any) any {
+	return mergePatch{obj: obj}
+}
+
+// JSONPatch returns a JSON Patch (RFC 6902) to be used as a request body.
+// The request body is read as "application/json-patch+json".
+func JSONPatch() any {
+	return jsonPatch{}
+}
+
+const jsonPatchOpComponent = "JSONPatchOperation"
+
+func (g *generator) patchSchema(p patch) (*kin.SchemaRef, error) {
+	switch p := p.(type) {
+	case mergePatch:
+		ref, err := g.schema(p.obj)
+		if err != nil {
+			return nil, err
+		}
+		return g.mergePatchSchema(ref)
+	case jsonPatch:
+		if _, ok := g.doc.Components.Schemas[jsonPatchOpComponent]; !ok {
+			g.doc.Components.Schemas[jsonPatchOpComponent] = &kin.SchemaRef{Value: jsonPatchOpSchema()}
+			g.inputOnly[jsonPatchOpComponent] = true
+		}
+		return &kin.SchemaRef{Value: &kin.Schema{
+			Type:  &kin.Types{kin.TypeArray},
+			Items: &kin.SchemaRef{Ref: "#/components/schemas/" + jsonPatchOpComponent},
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported patch type %T", p)
+	}
+}
+
+// mergePatchSchema derives the merge patch schema of the given schema.
+// Referenced component schemas are derived into their own components.
+func (g *generator) mergePatchSchema(ref *kin.SchemaRef) (*kin.SchemaRef, error) {
+	name, ok := strings.CutPrefix(ref.Ref, "#/components/schemas/")
+	if !ok {
+		if ref.Value == nil {
+			return ref, nil
+		}
+
+		schema, err := cloneSchema(ref.Value)
+		if err != nil {
+			return nil, err
+		}
+		if err = g.toMergePatch(schema); err != nil {
+			return nil, err
+		}
+		return &kin.SchemaRef{Value: schema}, nil
+	}
+
+	patchName := name + "MergePatch"
+	if _, ok = g.doc.Components.Schemas[patchName]; ok {
+		return &kin.SchemaRef{Ref: "#/components/schemas/" + patchName}, nil
+	}
+
+	comp, ok := g.doc.Components.Schemas[name]
+	if !ok || comp.Value == nil {
+		return ref, nil
+	}
+
+	schema, err := cloneSchema(comp.Value)
+	if err != nil {
+		return nil, err
+	}
+	g.inputOnly[patchName] = true
+	_, err = registerSchema(g.doc.Components.Schemas, patchName, schema, func() (*kin.SchemaRef, error) {
+		if err := g.toMergePatch(schema); err != nil {
+			return nil, err
+		}
+		return &kin.SchemaRef{Value: schema}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &kin.SchemaRef{Ref: "#/components/schemas/" + patchName}, nil
+}
+
+// toMergePatch turns the object schema into a merge patch schema.
+// Composed schemas are turned into merge patch schemas as well,
+// referring to the merge patch schemas of the components.
+func (g *generator) toMergePatch(schema *kin.Schema) error {
+	schema.Required = nil
+	removeProperties(schema, func(prop *kin.Schema) bool { return prop.ReadOnly })
+
+	// Array items and negated schemas are replaced as a whole.
+	items, not := schema.Items, schema.Not
+	schema.Items, schema.Not = nil, nil
+
+	var err error
+	forEachSubSchema(schema, func(ref *kin.SchemaRef) *kin.SchemaRef {
+		if err != nil {
+			return ref
+		}
+		var patched *kin.SchemaRef
+		if patched, err = g.mergePatchSchema(ref); err != nil {
+			return ref
+		}
+		return patched
+	})
+	schema.Items, schema.Not = items, not
+	if err != nil {
+		return err
+	}
+
+	for _, prop := range schema.Properties {
+		if prop.Value != nil {
+			prop.Value.Nullable = true
+		}
+	}
+
+	if schema.Discriminator != nil {
+		for val, ref := range schema.Discriminator.Mapping {
+			patched, err := g.mergePatchSchema(&kin.SchemaRef{Ref: ref})
+			if err != nil {
+				return err
+			}
+			schema.Discriminator.Mapping[val] = patched.Ref
+		}
+	}
+	return nil
+}
+
+func cloneSchema(schema *kin.Schema) (*kin.Schema, error) {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var clone kin.Schema
+	if err = json.Unmarshal(b, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+func jsonPatchOpSchema() *kin.Schema {
+	return &kin.Schema{
+		Type:        &kin.Types{kin.TypeObject},
+		Description: "A JSON Patch operation.",
+		Required:    []string{"op", "path"},
+		Properties: kin.Schemas{
+			"op": &kin.SchemaRef{Value: &kin.Schema{
+				Type: &kin.Types{kin.TypeString},
+				Enum: []any{"add", "remove", "replace", "move", "copy", "test"},
+			}},
+			"path": &kin.SchemaRef{Value: &kin.Schema{
+				Type:        &kin.Types{kin.TypeString},
+				Description: "A JSON Pointer to the target location.",
+			}},
+			"from": &kin.SchemaRef{Value: &kin.Schema{
+				Type:        &kin.Types{kin.TypeString},
+				Description: "A JSON Pointer to the source location of move and copy operations.",
+			}},
+			"value": &kin.SchemaRef{Value: &kin.Schema{
+				Description: "The value of add, replace and test operations.",
+			}},
+		},
+	}
+}
@@ -0,0 +1,139 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	kin "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Implementations describes the possible types of a polymorphic schema.
+type Implementations struct {
+	// Types are the types the schema can take. If empty, the
+	// types of the discriminator mapping are used.
+	Types []any
+
+	// AnyOf documents the schema as "anyOf" the types instead of "oneOf".
+	AnyOf bool
+
+	// DiscriminatorProperty is the name of the property used
+	// to discriminate between the types.
+	DiscriminatorProperty string
+
+	// DiscriminatorMapping maps the values of the discriminator property
+	// to their types.
+	DiscriminatorMapping map[string]any
+}
+
+type oneOf interface {
+	OpenAPIOneOf() []any
+}
+
+type anyOf interface {
+	OpenAPIAnyOf() []any
+}
+
+type discriminated interface {
+	OpenAPIDiscriminator() (prop string, mapping map[string]any)
+}
+
+// implementationsOf returns the implementations of the given type, either
+// registered with the generator or described by the type itself.
+func (g *generator) implementationsOf(t reflect.Type, v any) (Implementations, bool) {
+	if impls, ok := g.implementations[t]; ok {
+		return impls, true
+	}
+
+	var (
+		impls Implementations
+		found bool
+	)
+	if obj, ok := v.(oneOf); ok {
+		impls.Types = obj.OpenAPIOneOf()
+		found = true
+	}
+	if obj, ok := v.(anyOf); ok {
+		impls.Types = obj.OpenAPIAnyOf()
+		impls.AnyOf = true
+		found = true
+	}
+	if obj, ok := v.(discriminated); ok {
+		impls.DiscriminatorProperty, impls.DiscriminatorMapping = obj.OpenAPIDiscriminator()
+		found = true
+	}
+	return impls, found
+}
+
+func (g *generator) applyImplementations(name string, schema *kin.Schema, impls Implementations) error {
+	typs := impls.Types
+	if len(typs) == 0 {
+		keys := make([]string, 0, len(impls.DiscriminatorMapping))
+		for k := range impls.DiscriminatorMapping {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			typs = append(typs, impls.DiscriminatorMapping[k])
+		}
+	}
+	if len(typs) == 0 {
+		return fmt.Errorf("type %q defines implementations but returns none", name)
+	}
+
+	refs := make(kin.SchemaRefs, 0, len(typs))
+	for _, typ := range typs {
+		ref, err := g.schema(typ)
+		if err != nil {
+			return fmt.Errorf("generating implementation schema for %q: %w", name, err)
+		}
+		if !containsRef(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+
+	var disc *kin.Discriminator
+	if impls.DiscriminatorProperty != "" {
+		disc = &kin.Discriminator{PropertyName: impls.DiscriminatorProperty}
+		for val, typ := range impls.DiscriminatorMapping {
+			ref, err := g.schema(typ)
+			if err != nil {
+				return fmt.Errorf("generating discriminator schema for %q: %w", name, err)
+			}
+			if !strings.HasPrefix(ref.Ref, "#/components/schemas/") {
+				return fmt.Errorf("discriminator value %q of %q must map to an exported struct type", val, name)
+			}
+
+			if disc.Mapping == nil {
+				disc.Mapping = map[string]string{}
+			}
+			disc.Mapping[val] = ref.Ref
+		}
+	}
+
+	// The schema is entirely described by its implementations.
+	*schema = kin.Schema{
+		Nullable:      schema.Nullable,
+		Discriminator: disc,
+	}
+	if impls.AnyOf {
+		schema.AnyOf = refs
+	} else {
+		schema.OneOf = refs
+	}
+	return nil
+}
+
+func containsRef(refs kin.SchemaRefs, ref *kin.SchemaRef) bool {
+	if ref.Ref == "" {
+		return false
+	}
+	for _, r := range refs {
+		if r.Ref == ref.Ref {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"reflect"
+	"slices"
+
+	kin "github.com/getkin/kin-openapi/openapi3"
+)
+
+// RequiredPolicy determines how required properties are determined.
+type RequiredPolicy int
+
+const (
+	// RequiredExplicit only requires properties explicitly
+	// marked as required.
+	RequiredExplicit RequiredPolicy = iota
+
+	// RequiredInferred additionally infers the required properties
+	// from the struct fields. Fields that are always encoded, being neither
+	// nil-able nor tagged "omitempty" or "omitzero", are required.
+	// Pointer fields that are always encoded are nullable.
+	//
+	// Properties marked as "required" or "optional", by their attributes
+	// or field tags, are not inferred.
+	RequiredInferred
+)
+
+// applyInferredRequired infers the required and nullable
+// properties from the fields of the given struct type.
+func applyInferredRequired(schema *kin.Schema, t reflect.Type) {
+	explicit := map[string]bool{}
+	for _, e := range embeddedStructs(t) {
+		addExplicitRequired(explicit, reflect.New(e.Type).Elem().Interface())
+	}
+	addExplicitRequired(explicit, reflect.New(t).Elem().Interface())
+
+	fields := propertyFields(t)
+	for name, field := range fields {
+		tag, ok := field.Tag.Lookup(fieldTag)
+		if !ok {
+			continue
+		}
+		opts := parseFieldTag(tag)
+		if _, ok = opts[attrRequired]; ok {
+			explicit[name] = true
+		}
+		if _, ok = opts[attrOptional]; ok {
+			explicit[name] = true
+		}
+	}
+
+	var required []string
+	for name, field := range fields {
+		prop := schema.Properties[name]
+		if explicit[name] || field.OmitEmpty || field.OmitZero || prop == nil || prop.Value == nil {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Pointer:
+			prop.Value.Nullable = true
+		case reflect.Interface, reflect.Slice, reflect.Map:
+		default:
+			required = append(required, name)
+		}
+	}
+	addRequired(schema, required...)
+}
+
+// addExplicitRequired marks the properties the attributes of the given
+// object explicitly mark as required or optional.
+func addExplicitRequired(explicit map[string]bool, v any) {
+	attrs, _ := attributes(v)
+	for k, attrs := range attrs {
+		if slices.Contains(attrs, attrRequired) || slices.Contains(attrs, attrOptional) {
+			explicit[k] = true
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type operationCtxKey struct{}
+
+// Instrument returns a middleware storing the operation of the route
+// matching each request in the request context, to be used on the given
+// router itself. The operation is retrieved with OperationFromContext.
+//
+// The operations of the routes are resolved once, on the first request.
+func Instrument(r chi.Routes) func(http.Handler) http.Handler {
+	return InstrumentIn(r, defaultRegistry)
+}
+
+// InstrumentIn returns a middleware like Instrument, resolving the
+// operations of the handlers built with BuildHandlerIn with the registry.
+func InstrumentIn(r chi.Routes, reg *Registry) func(http.Handler) http.Handler {
+	return InstrumentFrom(ChiWalker(r), reg)
+}
+
+// InstrumentFrom returns a middleware like InstrumentIn, resolving the
+// operations of the routes of the given walker. The middleware wraps the
+// router of the walker, or is used on the router itself.
+func InstrumentFrom(w MatchWalker, reg *Registry) func(http.Handler) http.Handler {
+	ops := &routeOperations{walker: w, reg: registryOrDefault(reg)}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if op, ok := ops.match(req); ok {
+				req = req.WithContext(context.WithValue(req.Context(), operationCtxKey{}, op))
+			}
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// OperationFromContext returns the operation stored in the context by Instrument.
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	op, ok := ctx.Value(operationCtxKey{}).(Operation)
+	return op, ok
+}
+
+// routeOperations resolves the operations of the routes of a router.
+type routeOperations struct {
+	walker MatchWalker
+	reg    *Registry
+
+	once sync.Once
+	ops  map[string]Operation
+}
+
+// match returns the operation of the route matching the request.
+func (o *routeOperations) match(req *http.Request) (Operation, bool) {
+	route, ok := o.route(req)
+	if !ok {
+		return Operation{}, false
+	}
+
+	op, ok := o.operations()[route]
+	return op, ok
+}
+
+// operations returns the operations keyed by their method and route pattern.
+func (o *routeOperations) operations() map[string]Operation {
+	// The operations are resolved on the first request,
+	// once all routes have been registered.
+	o.once.Do(func() {
+		o.ops = map[string]Operation{}
+		_ = walkOperations(o.walker, o.reg, func(method, route string, op Operation, _ OperationSource) error {
+			o.ops[method+" "+route] = op
+			return nil
+		})
+	})
+	return o.ops
+}
+
+// route returns the method and route pattern of the route matching the request.
+func (o *routeOperations) route(req *http.Request) (string, bool) {
+	method, route, ok := o.walker.Match(req)
+	if !ok {
+		return "", false
+	}
+	return method + " " + route, true
+}
@@ -0,0 +1,87 @@
+package openapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gamefabric/openapi"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrument(t *testing.T) {
+	var (
+		got   openapi.Operation
+		found bool
+	)
+	newHandler := func() http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			got, found = openapi.OperationFromContext(req.Context())
+		}
+	}
+
+	mux := chi.NewMux()
+	mux.Use(openapi.Instrument(mux))
+	mux.Use(openapi.Op().Tag("api").Build())
+
+	mux.Route("/items", func(r chi.Router) {
+		r.With(openapi.Op().
+			ID("get-item").
+			Tag("items").
+			RequiresAuth("bearer", openapi.SecurityBearer, "read").
+			Returns(http.StatusOK, "OK", &TestSimpleObject{}).
+			Returns(http.StatusNotFound, "Not Found", nil).
+			Build()).Get("/{name}", newHandler())
+	})
+	mux.Get("/handler", openapi.Op().
+		ID("handler").
+		Public().
+		BuildHandler()(newHandler()))
+	mux.Get("/undocumented", newHandler())
+
+	t.Run("middleware", func(t *testing.T) {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/foo", nil))
+
+		require.True(t, found)
+		assert.Equal(t, "get-item", got.ID())
+		assert.Equal(t, []string{"api", "items"}, got.Tags())
+		assert.Equal(t, [][]openapi.SecurityRequirement{
+			{openapi.Requirement("bearer", openapi.SecurityBearer, "read")},
+		}, got.Security())
+		require.Len(t, got.Responses(), 2)
+		assert.Equal(t, http.StatusOK, got.Responses()[0].Code())
+		assert.Equal(t, "Not Found", got.Responses()[1].Description())
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/handler", nil))
+
+		require.True(t, found)
+		assert.Equal(t, "handler", got.ID())
+		assert.True(t, got.Public())
+	})
+
+	t.Run("undocumented", func(t *testing.T) {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/undocumented", nil))
+
+		require.True(t, found)
+		assert.Empty(t, got.ID())
+		assert.Equal(t, []string{"api"}, got.Tags())
+	})
+
+	t.Run("subrouter", func(t *testing.T) {
+		sub := chi.NewMux()
+		sub.Route("/sub", func(r chi.Router) {
+			r.Use(openapi.Instrument(r))
+			r.With(openapi.Op().ID("get-sub-item").Build()).Get("/{name}", newHandler())
+		})
+
+		found = false
+		sub.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/sub/foo", nil))
+
+		require.True(t, found)
+		assert.Equal(t, "get-sub-item", got.ID())
+	})
+}
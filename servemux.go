@@ -0,0 +1,140 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// serveMuxMethods are the methods matched by patterns without a method,
+// in the order they are walked.
+var serveMuxMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// ServeMux wraps an http.ServeMux, tracking the registered routes
+// as http.ServeMux cannot be walked.
+type ServeMux struct {
+	mux *http.ServeMux
+
+	mu     sync.Mutex
+	routes []serveMuxRoute
+}
+
+type serveMuxRoute struct {
+	pattern     string
+	handler     http.Handler
+	middlewares []func(http.Handler) http.Handler
+}
+
+// NewServeMux returns a new ServeMux.
+func NewServeMux() *ServeMux {
+	return &ServeMux{mux: http.NewServeMux()}
+}
+
+// Handle registers the handler for the given pattern, wrapped by the
+// given middlewares, the first being the outermost.
+// Operations built with Build are discovered in the middlewares.
+func (m *ServeMux) Handle(pattern string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) {
+	h := handler
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	m.mux.Handle(pattern, h)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.routes = append(m.routes, serveMuxRoute{
+		pattern:     pattern,
+		handler:     handler,
+		middlewares: middlewares,
+	})
+}
+
+// HandleFunc registers the handler func for the given pattern, wrapped
+// by the given middlewares, the first being the outermost.
+func (m *ServeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), middlewares ...func(http.Handler) http.Handler) {
+	m.Handle(pattern, http.HandlerFunc(handler), middlewares...)
+}
+
+// ServeHTTP dispatches the request to the handler of the matching pattern.
+func (m *ServeMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	m.mux.ServeHTTP(rw, req)
+}
+
+// Walk walks the registered routes in registration order.
+// Routes of patterns without a method are walked for each method,
+// their operation IDs being suffixed with the method.
+func (m *ServeMux) Walk(fn WalkFunc) error {
+	m.mu.Lock()
+	routes := append([]serveMuxRoute{}, m.routes...)
+	m.mu.Unlock()
+
+	for _, r := range routes {
+		method, path := parseServeMuxPattern(r.pattern)
+
+		methods := serveMuxMethods
+		if method != "" {
+			methods = []string{method}
+		}
+		for _, method := range methods {
+			if err := fn(method, path, r.handler, r.middlewares...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Match returns the method and route, as walked, of the route matching
+// the request. Routes of patterns without a method match with the method
+// of the request.
+func (m *ServeMux) Match(req *http.Request) (method, route string, ok bool) {
+	_, pattern := m.mux.Handler(req)
+	if pattern == "" {
+		return "", "", false
+	}
+
+	method, route = parseServeMuxPattern(pattern)
+	if method == "" {
+		method = req.Method
+	}
+	return method, route, true
+}
+
+// parseServeMuxPattern returns the method and the OpenAPI path of the
+// given http.ServeMux pattern, in the form "[METHOD ][HOST]/[PATH]".
+//
+// Remainder wildcards "{name...}" become path parameters "{name}"
+// and end anchors "{$}" are removed.
+func parseServeMuxPattern(pattern string) (method, path string) {
+	if m, rest, ok := strings.Cut(pattern, " "); ok && !strings.Contains(m, "/") {
+		method = m
+		pattern = strings.TrimLeft(rest, " \t")
+	}
+
+	// The host is not part of the path.
+	if i := strings.Index(pattern, "/"); i > 0 {
+		pattern = pattern[i:]
+	}
+
+	segs := strings.Split(pattern, "/")
+	for i, seg := range segs {
+		switch {
+		case seg == "{$}":
+			segs[i] = ""
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "...}"):
+			segs[i] = strings.TrimSuffix(seg, "...}") + "}"
+		}
+	}
+	return method, strings.Join(segs, "/")
+}
@@ -0,0 +1,146 @@
+package openapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gamefabric/openapi"
+	kin "github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeMux(t *testing.T) {
+	var called bool
+	handler := func(rw http.ResponseWriter, req *http.Request) { called = true }
+
+	mux := openapi.NewServeMux()
+	mux.HandleFunc("GET /items/{name}", handler, openapi.Op().
+		ID("get-item").
+		Param(openapi.PathParameter("name", "the item name")).
+		Returns(http.StatusOK, "OK", nil).
+		Build())
+	mux.HandleFunc("POST /items/{$}", handler, openapi.Op().ID("create-item").Returns(http.StatusCreated, "Created", nil).Build())
+	mux.HandleFunc("GET example.com/files/{path...}", openapi.Op().
+		ID("get-file").
+		Param(openapi.PathParameter("path", "the file path")).
+		Returns(http.StatusOK, "OK", nil).
+		BuildHandler()(handler))
+	mux.HandleFunc("/health", handler, openapi.Op().ID("health").Returns(http.StatusOK, "OK", nil).Build())
+	mux.HandleFunc("GET /undocumented", handler)
+
+	doc, err := openapi.BuildSpecFrom(mux, openapi.SpecConfig{})
+	require.NoError(t, err)
+
+	paths := doc.Paths.Map()
+	require.Len(t, paths, 4)
+	require.Contains(t, paths, "/items/{name}")
+	assert.Equal(t, "get-item", paths["/items/{name}"].Get.OperationID)
+	require.Contains(t, paths, "/items/")
+	assert.Equal(t, "create-item", paths["/items/"].Post.OperationID)
+	require.Contains(t, paths, "/files/{path}")
+	assert.Equal(t, "get-file", paths["/files/{path}"].Get.OperationID)
+	require.Contains(t, paths, "/health")
+	assert.Equal(t, "health-get", paths["/health"].Get.OperationID)
+	assert.Equal(t, "health-post", paths["/health"].Post.OperationID)
+
+	doc.Info = &kin.Info{Title: "Test Server", Version: "1"}
+	require.NoError(t, doc.Validate(context.Background()))
+
+	routes, err := openapi.InspectFrom(mux, openapi.SpecConfig{})
+	require.NoError(t, err)
+	ids := map[string]string{}
+	for _, r := range routes {
+		ids[r.Method+" "+r.Path] = r.Operation.ID()
+	}
+	assert.Equal(t, "health-get", ids["GET /health"])
+	assert.Equal(t, "health-post", ids["POST /health"])
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/foo", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, called)
+}
+
+func TestServeMuxRuntime(t *testing.T) {
+	var (
+		got   openapi.Operation
+		found bool
+	)
+	handler := func(rw http.ResponseWriter, req *http.Request) {
+		got, found = openapi.OperationFromContext(req.Context())
+	}
+
+	mux := openapi.NewServeMux()
+	mux.HandleFunc("GET /items/{name}", handler, openapi.Op().
+		ID("get-item").
+		RequiresAuth("bearer", openapi.SecurityBearer).
+		Build())
+	mux.HandleFunc("GET /files/{path...}", openapi.Op().ID("get-file").Public().BuildHandler()(handler))
+	mux.HandleFunc("/health", handler, openapi.Op().ID("health").Public().Build())
+
+	h := openapi.RequireAuthFrom(mux, openapi.AuthConfig{
+		Bearer: func(_ *http.Request, _, token string) ([]string, error) {
+			return nil, nil
+		},
+	})(openapi.InstrumentFrom(mux, nil)(mux))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     http.Header
+		wantStatus int
+		wantID     string
+	}{
+		{
+			name:       "secured without credentials",
+			method:     http.MethodGet,
+			path:       "/items/foo",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "secured with token",
+			method:     http.MethodGet,
+			path:       "/items/foo",
+			header:     http.Header{"Authorization": {"Bearer token"}},
+			wantStatus: http.StatusOK,
+			wantID:     "get-item",
+		},
+		{
+			name:       "remainder wildcard",
+			method:     http.MethodGet,
+			path:       "/files/a/b",
+			wantStatus: http.StatusOK,
+			wantID:     "get-file",
+		},
+		{
+			name:       "pattern without method",
+			method:     http.MethodPost,
+			path:       "/health",
+			wantStatus: http.StatusOK,
+			wantID:     "health-post",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, found = openapi.Operation{}, false
+			req := httptest.NewRequest(test.method, test.path, nil)
+			for k, v := range test.header {
+				req.Header[k] = v
+			}
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.wantStatus, rec.Code)
+			if test.wantID != "" {
+				require.True(t, found)
+				assert.Equal(t, test.wantID, got.ID())
+			}
+		})
+	}
+}
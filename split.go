@@ -0,0 +1,310 @@
+package openapi
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	kin "github.com/getkin/kin-openapi/openapi3"
+)
+
+// inputSuffix is the suffix of the input variants of component schemas.
+const inputSuffix = "Input"
+
+// splitInputSchemas splits the component schemas with read only or
+// write only properties into an input and an output variant.
+//
+// The input variant omits the read only properties and is referred to
+// by request bodies. The component itself becomes the output variant,
+// omitting the write only properties, and is referred to by responses.
+func (g *generator) splitInputSchemas() error {
+	s := &schemaSplitter{
+		comps:  g.doc.Components.Schemas,
+		split:  g.splitComponents(),
+		inputs: kin.Schemas{},
+	}
+
+	for _, item := range g.doc.Paths.Map() {
+		for _, op := range item.Operations() {
+			if op.RequestBody != nil && op.RequestBody.Value != nil {
+				if err := s.inputContent(op.RequestBody.Value.Content); err != nil {
+					return err
+				}
+			}
+			if op.Responses == nil {
+				continue
+			}
+			for _, res := range op.Responses.Map() {
+				if res.Value != nil {
+					outputContent(res.Value.Content)
+				}
+			}
+		}
+	}
+	for _, body := range g.doc.Components.RequestBodies {
+		if body.Value == nil {
+			continue
+		}
+		if err := s.inputContent(body.Value.Content); err != nil {
+			return err
+		}
+	}
+	for _, res := range g.doc.Components.Responses {
+		if res.Value != nil {
+			outputContent(res.Value.Content)
+		}
+	}
+
+	// The output schemas are derived last, as the input
+	// schemas are derived from the unmodified components.
+	for name := range s.split {
+		toOutput(s.comps[name].Value, map[*kin.Schema]bool{})
+	}
+	maps.Copy(s.comps, s.inputs)
+	return nil
+}
+
+// schemaSplitter derives the input schemas of split component schemas.
+type schemaSplitter struct {
+	comps  kin.Schemas
+	split  map[string]bool
+	inputs kin.Schemas
+}
+
+// splitComponents returns the component schemas to split, being those with
+// read only or write only properties, or referring to components to split.
+func (g *generator) splitComponents() map[string]bool {
+	split := map[string]bool{}
+	refs := map[string]map[string]bool{}
+	for name, comp := range g.doc.Components.Schemas {
+		if comp.Value == nil || g.inputOnly[name] {
+			continue
+		}
+
+		refs[name] = map[string]bool{}
+		if inspectReadWrite(comp.Value, refs[name], map[*kin.Schema]bool{}) {
+			split[name] = true
+		}
+	}
+
+	// Propagate the split to the referring components until all are found.
+	for changed := true; changed; {
+		changed = false
+		for name, refNames := range refs {
+			if split[name] {
+				continue
+			}
+			for refName := range refNames {
+				if split[refName] {
+					split[name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return split
+}
+
+// inspectReadWrite reports if the schema has read only or write only properties,
+// collecting the names of the component schemas it refers to.
+func inspectReadWrite(schema *kin.Schema, refs map[string]bool, seen map[*kin.Schema]bool) bool {
+	if seen[schema] {
+		return false
+	}
+	seen[schema] = true
+
+	var readWrite bool
+	for _, prop := range schema.Properties {
+		if prop.Value != nil && (prop.Value.ReadOnly || prop.Value.WriteOnly) {
+			readWrite = true
+		}
+	}
+	for _, ref := range subSchemas(schema) {
+		if name, ok := strings.CutPrefix(ref.Ref, "#/components/schemas/"); ok {
+			refs[name] = true
+			continue
+		}
+		if ref.Value != nil && inspectReadWrite(ref.Value, refs, seen) {
+			readWrite = true
+		}
+	}
+	return readWrite
+}
+
+func (s *schemaSplitter) inputContent(content kin.Content) error {
+	for _, mt := range content {
+		if mt.Schema == nil {
+			continue
+		}
+		if mt.Schema.Ref != "" {
+			ref, err := s.inputRef(mt.Schema)
+			if err != nil {
+				return err
+			}
+			mt.Schema = ref
+			continue
+		}
+		if mt.Schema.Value == nil {
+			continue
+		}
+
+		// Inline schemas can share nested schemas with components,
+		// so they are cloned before being modified.
+		schema, err := cloneSchema(mt.Schema.Value)
+		if err != nil {
+			return err
+		}
+		if err = s.toInput(schema, map[*kin.Schema]bool{}); err != nil {
+			return err
+		}
+		mt.Schema = &kin.SchemaRef{Value: schema}
+	}
+	return nil
+}
+
+func outputContent(content kin.Content) {
+	for _, mt := range content {
+		if mt.Schema != nil && mt.Schema.Ref == "" && mt.Schema.Value != nil {
+			toOutput(mt.Schema.Value, map[*kin.Schema]bool{})
+		}
+	}
+}
+
+// toInput removes the read only properties from the schema, referring
+// to the input schemas of the split component schemas.
+func (s *schemaSplitter) toInput(schema *kin.Schema, seen map[*kin.Schema]bool) error {
+	if seen[schema] {
+		return nil
+	}
+	seen[schema] = true
+
+	removeProperties(schema, func(prop *kin.Schema) bool { return prop.ReadOnly })
+
+	var err error
+	forEachSubSchema(schema, func(ref *kin.SchemaRef) *kin.SchemaRef {
+		if err != nil {
+			return ref
+		}
+		if ref.Ref != "" {
+			var input *kin.SchemaRef
+			if input, err = s.inputRef(ref); err != nil {
+				return ref
+			}
+			return input
+		}
+		if ref.Value != nil {
+			err = s.toInput(ref.Value, seen)
+		}
+		return ref
+	})
+	if err != nil {
+		return err
+	}
+
+	if schema.Discriminator != nil {
+		for val, ref := range schema.Discriminator.Mapping {
+			input, err := s.inputRef(&kin.SchemaRef{Ref: ref})
+			if err != nil {
+				return err
+			}
+			schema.Discriminator.Mapping[val] = input.Ref
+		}
+	}
+	return nil
+}
+
+// toOutput removes the write only properties from the schema.
+func toOutput(schema *kin.Schema, seen map[*kin.Schema]bool) {
+	if seen[schema] {
+		return
+	}
+	seen[schema] = true
+
+	removeProperties(schema, func(prop *kin.Schema) bool { return prop.WriteOnly })
+	forEachSubSchema(schema, func(ref *kin.SchemaRef) *kin.SchemaRef {
+		if ref.Ref == "" && ref.Value != nil {
+			toOutput(ref.Value, seen)
+		}
+		return ref
+	})
+}
+
+// inputRef returns the reference to the input schema of the referenced
+// component schema, deriving the input schema if needed.
+func (s *schemaSplitter) inputRef(ref *kin.SchemaRef) (*kin.SchemaRef, error) {
+	name, ok := strings.CutPrefix(ref.Ref, "#/components/schemas/")
+	if !ok || !s.split[name] {
+		return ref, nil
+	}
+
+	inputName := name + inputSuffix
+	inputRef := &kin.SchemaRef{Ref: "#/components/schemas/" + inputName}
+	if _, ok = s.inputs[inputName]; ok {
+		return inputRef, nil
+	}
+
+	schema, err := cloneSchema(s.comps[name].Value)
+	if err != nil {
+		return nil, err
+	}
+	_, err = registerSchema(s.inputs, inputName, schema, func() (*kin.SchemaRef, error) {
+		if err := s.toInput(schema, map[*kin.Schema]bool{}); err != nil {
+			return nil, err
+		}
+		return &kin.SchemaRef{Value: schema}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inputRef, nil
+}
+
+func removeProperties(schema *kin.Schema, remove func(prop *kin.Schema) bool) {
+	for name, prop := range schema.Properties {
+		if prop.Value == nil || !remove(prop.Value) {
+			continue
+		}
+		delete(schema.Properties, name)
+		schema.Required = slices.DeleteFunc(schema.Required, func(req string) bool { return req == name })
+	}
+	if len(schema.Required) == 0 {
+		schema.Required = nil
+	}
+}
+
+// subSchemas returns the schemas directly nested in the given schema.
+func subSchemas(schema *kin.Schema) []*kin.SchemaRef {
+	var refs []*kin.SchemaRef
+	forEachSubSchema(schema, func(ref *kin.SchemaRef) *kin.SchemaRef {
+		refs = append(refs, ref)
+		return ref
+	})
+	return refs
+}
+
+// forEachSubSchema replaces the schemas directly nested in the given
+// schema with the result of fn.
+func forEachSubSchema(schema *kin.Schema, fn func(ref *kin.SchemaRef) *kin.SchemaRef) {
+	for name, prop := range schema.Properties {
+		if prop != nil {
+			schema.Properties[name] = fn(prop)
+		}
+	}
+	for _, refs := range []kin.SchemaRefs{schema.AllOf, schema.AnyOf, schema.OneOf} {
+		for i, ref := range refs {
+			if ref != nil {
+				refs[i] = fn(ref)
+			}
+		}
+	}
+	if schema.Items != nil {
+		schema.Items = fn(schema.Items)
+	}
+	if schema.AdditionalProperties.Schema != nil {
+		schema.AdditionalProperties.Schema = fn(schema.AdditionalProperties.Schema)
+	}
+	if schema.Not != nil {
+		schema.Not = fn(schema.Not)
+	}
+}
@@ -0,0 +1,110 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	kin "github.com/getkin/kin-openapi/openapi3"
+)
+
+// fieldTag is the struct tag containing inline field metadata.
+const fieldTag = "openapi"
+
+// fieldTagFlags are the options of the field tag without a value.
+var fieldTagFlags = map[string]bool{
+	attrRequired:   true,
+	attrOptional:   true,
+	attrReadOnly:   true,
+	attrWriteOnly:  true,
+	attrNullable:   true,
+	attrDeprecated: true,
+	attrSensitive:  true,
+}
+
+// fieldTagKeys are the options of the field tag with a value.
+var fieldTagKeys = map[string]bool{
+	"description": true,
+	"format":      true,
+	"example":     true,
+	"default":     true,
+}
+
+// applyFieldTags applies the metadata in the field tags of the
+// struct fields to their property schemas.
+//
+// The tag is in the form `openapi:"description=Some text,format=uuid,readonly"`.
+// Commas in values are kept as long as the text after them is not a known option.
+func applyFieldTags(schema *kin.Schema, t reflect.Type) {
+	var required []string
+	for name, field := range propertyFields(t) {
+		tag, ok := field.Tag.Lookup(fieldTag)
+		if !ok || tag == "" {
+			continue
+		}
+
+		prop := schema.Properties[name]
+		if prop == nil || prop.Value == nil {
+			continue
+		}
+
+		for key, val := range parseFieldTag(tag) {
+			switch key {
+			case "description":
+				prop.Value.Description = val
+			case "format":
+				prop.Value.Format = val
+			case "example":
+				prop.Value.Example = typedValue(prop.Value, val)
+			case "default":
+				prop.Value.Default = typedValue(prop.Value, val)
+			default:
+				if applyAttr(prop.Value, key) {
+					required = append(required, name)
+				}
+			}
+		}
+	}
+	addRequired(schema, required...)
+}
+
+func parseFieldTag(tag string) map[string]string {
+	opts := map[string]string{}
+
+	var last string
+	for _, part := range strings.Split(tag, ",") {
+		key, val, hasVal := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		switch {
+		case !hasVal && fieldTagFlags[key]:
+			opts[key] = ""
+			last = ""
+		case hasVal && fieldTagKeys[key]:
+			opts[key] = val
+			last = key
+		case last != "":
+			opts[last] += "," + part
+		}
+	}
+	return opts
+}
+
+// typedValue converts the string value to the type of the schema,
+// falling back to the string if it cannot be converted.
+func typedValue(schema *kin.Schema, val string) any {
+	switch {
+	case schema.Type.Is(kin.TypeInteger):
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return n
+		}
+	case schema.Type.Is(kin.TypeNumber):
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return f
+		}
+	case schema.Type.Is(kin.TypeBoolean):
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return val
+}
@@ -0,0 +1,88 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/netip"
+	"reflect"
+	"time"
+
+	kin "github.com/getkin/kin-openapi/openapi3"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// builtinTypeOverrides contains the schemas of well-known types whose
+// JSON representation differs from their Go layout.
+//
+// The big number types implement their marshalers on pointers, so they are
+// only represented as documented when addressable, e.g. as pointers or as
+// fields of structs marshaled through a pointer. Otherwise they marshal as
+// empty objects.
+//
+// url.URL is not overridden, as it marshals as an object. A wrapper type
+// implementing encoding.TextMarshaler is needed to represent it as a string.
+var builtinTypeOverrides = map[reflect.Type]*kin.Schema{
+	reflect.TypeOf(time.Time{}):       {Type: &kin.Types{kin.TypeString}, Format: "date-time"},
+	reflect.TypeOf(time.Duration(0)):  {Type: &kin.Types{kin.TypeInteger}, Format: "int64"},
+	reflect.TypeOf(json.RawMessage{}): {},
+	reflect.TypeOf(net.IP{}):          {Type: &kin.Types{kin.TypeString}},
+	reflect.TypeOf(netip.Addr{}):      {Type: &kin.Types{kin.TypeString}},
+	reflect.TypeOf(netip.AddrPort{}):  {Type: &kin.Types{kin.TypeString}},
+	reflect.TypeOf(netip.Prefix{}):    {Type: &kin.Types{kin.TypeString}},
+	reflect.TypeOf(big.Int{}):         {Type: &kin.Types{kin.TypeInteger}},
+	reflect.TypeOf(big.Float{}):       {Type: &kin.Types{kin.TypeString}},
+	reflect.TypeOf(big.Rat{}):         {Type: &kin.Types{kin.TypeString}},
+}
+
+// isOverridden determines if the schema of the given type is not
+// generated from its Go layout.
+func (g *generator) isOverridden(t reflect.Type) bool {
+	if _, ok := g.typeOverrides[t]; ok {
+		return true
+	}
+	return isTextMarshaler(t)
+}
+
+// applyTypeOverride replaces the schema with a copy of the override of the
+// given type, returning true if the schema was replaced. The override is
+// copied, as the schema is further customized for each of its uses.
+//
+// Types without an explicit override that implement encoding.TextMarshaler
+// but not json.Marshaler are represented as strings.
+func (g *generator) applyTypeOverride(t reflect.Type, schema *kin.Schema) (bool, error) {
+	override, ok := g.typeOverrides[t]
+	if !ok {
+		if !isTextMarshaler(t) {
+			return false, nil
+		}
+		override = &kin.Schema{Type: &kin.Types{kin.TypeString}}
+	}
+
+	clone, err := cloneSchema(override)
+	if err != nil {
+		return false, err
+	}
+
+	nullable := schema.Nullable
+	*schema = *clone
+	schema.Nullable = schema.Nullable || nullable
+	return true, nil
+}
+
+func isTextMarshaler(t reflect.Type) bool {
+	if _, ok := reflect.New(t).Elem().Interface().(openAPIType); ok {
+		return false
+	}
+
+	ptr := reflect.PointerTo(t)
+	if t.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType) {
+		return false
+	}
+	return t.Implements(textMarshalerType) || ptr.Implements(textMarshalerType)
+}
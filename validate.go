@@ -0,0 +1,210 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	kin "github.com/getkin/kin-openapi/openapi3"
+)
+
+// validationFormats maps validation rules to schema formats.
+var validationFormats = map[string]string{
+	"email":    "email",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"url":      "uri",
+	"uri":      "uri",
+	"ip":       "ip",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+	"datetime": "date-time",
+}
+
+// validationPatterns maps validation rules to schema patterns.
+var validationPatterns = map[string]string{
+	"alpha":       "^[a-zA-Z]+$",
+	"alphanum":    "^[a-zA-Z0-9]+$",
+	"numeric":     "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+	"hexadecimal": "^(0[xX])?[0-9a-fA-F]+$",
+	"lowercase":   "^[^A-Z]*$",
+	"uppercase":   "^[^a-z]*$",
+}
+
+// applyValidation translates the validation rules in the given tag of
+// the struct fields into constraints on their property schemas.
+//
+// The supported rules are "required", "min", "max", "len", "gt", "gte",
+// "lt", "lte", "oneof", "dive" and the rules in validationFormats and
+// validationPatterns. All other rules are ignored.
+func applyValidation(schema *kin.Schema, t reflect.Type, tag string) {
+	var required []string
+	for name, field := range propertyFields(t) {
+		rules, ok := field.Tag.Lookup(tag)
+		if !ok || rules == "" || rules == "-" {
+			continue
+		}
+
+		prop := schema.Properties[name]
+		if prop == nil || prop.Value == nil {
+			continue
+		}
+
+		if applyRules(prop.Value, strings.Split(rules, ",")) {
+			required = append(required, name)
+		}
+	}
+	addRequired(schema, required...)
+}
+
+// applyRules applies the given validation rules to the schema,
+// returning true if the rules require the value to be set.
+func applyRules(schema *kin.Schema, rules []string) bool {
+	var required bool
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			applyDive(schema, rules[i+1:])
+			return required
+		case "min", "gte":
+			applyMin(schema, param, false)
+		case "max", "lte":
+			applyMax(schema, param, false)
+		case "gt":
+			applyMin(schema, param, true)
+		case "lt":
+			applyMax(schema, param, true)
+		case "len":
+			applyMin(schema, param, false)
+			applyMax(schema, param, false)
+		case "oneof":
+			applyOneOf(schema, param)
+		default:
+			if format, ok := validationFormats[name]; ok {
+				schema.Format = format
+			}
+			if pattern, ok := validationPatterns[name]; ok {
+				schema.Pattern = pattern
+			}
+		}
+	}
+	return required
+}
+
+func applyDive(schema *kin.Schema, rules []string) {
+	var elem *kin.SchemaRef
+	switch {
+	case schema.Items != nil:
+		elem = schema.Items
+	case schema.AdditionalProperties.Schema != nil:
+		elem = schema.AdditionalProperties.Schema
+	}
+	if elem == nil || elem.Value == nil {
+		return
+	}
+
+	// Key validation is not supported, skip to the value rules.
+	if len(rules) > 0 && rules[0] == "keys" {
+		for i, rule := range rules {
+			if rule == "endkeys" {
+				rules = rules[i+1:]
+				break
+			}
+		}
+	}
+
+	applyRules(elem.Value, rules)
+}
+
+func applyMin(schema *kin.Schema, param string, exclusive bool) {
+	switch {
+	case schema.Type.Is(kin.TypeString):
+		if n, ok := parseLength(param, exclusive, 1); ok {
+			schema.MinLength = n
+		}
+	case schema.Type.Is(kin.TypeArray):
+		if n, ok := parseLength(param, exclusive, 1); ok {
+			schema.MinItems = n
+		}
+	case schema.Type.Is(kin.TypeObject):
+		if n, ok := parseLength(param, exclusive, 1); ok {
+			schema.MinProps = n
+		}
+	case schema.Type.Is(kin.TypeInteger), schema.Type.Is(kin.TypeNumber):
+		if f, err := strconv.ParseFloat(param, 64); err == nil {
+			schema.Min = &f
+			schema.ExclusiveMin = exclusive
+		}
+	}
+}
+
+func applyMax(schema *kin.Schema, param string, exclusive bool) {
+	switch {
+	case schema.Type.Is(kin.TypeString):
+		if n, ok := parseLength(param, exclusive, -1); ok {
+			schema.MaxLength = &n
+		}
+	case schema.Type.Is(kin.TypeArray):
+		if n, ok := parseLength(param, exclusive, -1); ok {
+			schema.MaxItems = &n
+		}
+	case schema.Type.Is(kin.TypeObject):
+		if n, ok := parseLength(param, exclusive, -1); ok {
+			schema.MaxProps = &n
+		}
+	case schema.Type.Is(kin.TypeInteger), schema.Type.Is(kin.TypeNumber):
+		if f, err := strconv.ParseFloat(param, 64); err == nil {
+			schema.Max = &f
+			schema.ExclusiveMax = exclusive
+		}
+	}
+}
+
+// parseLength parses a length parameter, shifting it by the
+// given delta if the bound is exclusive.
+func parseLength(param string, exclusive bool, delta int64) (uint64, bool) {
+	n, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	if exclusive {
+		n += delta
+	}
+	if n < 0 {
+		return 0, false
+	}
+	return uint64(n), true
+}
+
+func applyOneOf(schema *kin.Schema, param string) {
+	vals := strings.Fields(param)
+	if len(vals) == 0 {
+		return
+	}
+
+	enum := make([]any, 0, len(vals))
+	for _, val := range vals {
+		val = strings.Trim(val, "'")
+		switch {
+		case schema.Type.Is(kin.TypeInteger):
+			n, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return
+			}
+			enum = append(enum, n)
+		case schema.Type.Is(kin.TypeNumber):
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return
+			}
+			enum = append(enum, f)
+		default:
+			enum = append(enum, val)
+		}
+	}
+	schema.Enum = enum
+}
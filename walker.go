@@ -0,0 +1,99 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// WalkFunc is called for each route of a router, with the route path in
+// OpenAPI form, the route handler and the middlewares wrapping the handler.
+type WalkFunc func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error
+
+// Walker walks the routes of a router, allowing specs to be built from
+// any router with BuildSpecFrom.
+//
+// The operation of a route is discovered from the arguments of the WalkFunc.
+// Each middleware is probed by calling it with an internal probe handler:
+// the middlewares built with Build return a handler carrying their operation
+// when given the probe, and any other handler otherwise. All other middlewares
+// are expected to wrap the probe in another handler, and are ignored.
+// The operations of the handlers built with BuildHandler are looked up by
+// the handler itself in the registry.
+//
+// Walkers must therefore pass the handler as registered, before it is
+// wrapped by the middlewares, and the middlewares of the route from the
+// outermost to the innermost. OperationOf performs the same discovery.
+type Walker interface {
+	Walk(fn WalkFunc) error
+}
+
+// MatchWalker is a walker able to match requests to the routes it walks,
+// allowing the operations of requests to be resolved with InstrumentFrom
+// and RequireAuthFrom.
+type MatchWalker interface {
+	Walker
+
+	// Match returns the method and route, as walked, of the route
+	// matching the request.
+	Match(req *http.Request) (method, route string, ok bool)
+}
+
+// ChiWalker returns a walker of the routes of the chi router.
+func ChiWalker(r chi.Routes) MatchWalker {
+	return chiWalker{routes: r}
+}
+
+type chiWalker struct {
+	routes chi.Routes
+}
+
+func (w chiWalker) Walk(fn WalkFunc) error {
+	return chi.Walk(w.routes, chi.WalkFunc(fn))
+}
+
+func (w chiWalker) Match(req *http.Request) (method, route string, ok bool) {
+	// Subrouters are matched with the path remaining to be routed.
+	var path string
+	if rctx := chi.RouteContext(req.Context()); rctx != nil {
+		path = rctx.RoutePath
+	}
+	if path == "" {
+		path = req.URL.RawPath
+	}
+	if path == "" {
+		path = req.URL.Path
+	}
+
+	rctx := chi.NewRouteContext()
+	if !w.routes.Match(rctx, req.Method, path) {
+		return "", "", false
+	}
+	return req.Method, rctx.RoutePattern(), true
+}
+
+// OperationOf returns the operation of a route with the given handler and
+// middlewares, merged from all its sources, and the sources it was found in.
+// The handlers built with BuildHandlerIn are looked up in the registry,
+// or in the default registry if nil.
+//
+// No operation was found if the returned source is zero.
+func OperationOf(reg *Registry, handler http.Handler, middlewares ...func(http.Handler) http.Handler) (Operation, OperationSource) {
+	var (
+		op  Operation
+		src OperationSource
+	)
+	for _, m := range middlewares {
+		h := m(opHandler{})
+		if oph, ok := h.(opHandler); ok {
+			op = op.Merge(oph.Op)
+			src |= SourceMiddleware
+		}
+	}
+
+	if o, ok := registryOrDefault(reg).op(handler); ok {
+		op = op.Merge(o)
+		src |= SourceHandler
+	}
+	return op, src
+}
@@ -0,0 +1,79 @@
+package openapi_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gamefabric/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRoute struct {
+	method      string
+	path        string
+	handler     http.Handler
+	middlewares []func(http.Handler) http.Handler
+}
+
+type testRouter []testRoute
+
+func (r testRouter) Walk(fn openapi.WalkFunc) error {
+	for _, route := range r {
+		if err := fn(route.method, route.path, route.handler, route.middlewares...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestBuildSpecFrom(t *testing.T) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+	logger := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) { next.ServeHTTP(rw, req) })
+	}
+
+	router := testRouter{
+		{
+			method:  http.MethodGet,
+			path:    "/items",
+			handler: handler,
+			middlewares: []func(http.Handler) http.Handler{
+				logger,
+				openapi.Op().ID("list-items").Tag("items").Build(),
+			},
+		},
+		{
+			method:  http.MethodPost,
+			path:    "/items",
+			handler: openapi.Op().ID("create-item").BuildHandler()(handler),
+		},
+		{
+			method:  http.MethodGet,
+			path:    "/health",
+			handler: handler,
+		},
+	}
+
+	doc, err := openapi.BuildSpecFrom(router, openapi.SpecConfig{})
+	require.NoError(t, err)
+
+	paths := doc.Paths.Map()
+	require.Len(t, paths, 1)
+	require.Contains(t, paths, "/items")
+	assert.Equal(t, "list-items", paths["/items"].Get.OperationID)
+	assert.Equal(t, []string{"items"}, paths["/items"].Get.Tags)
+	assert.Equal(t, "create-item", paths["/items"].Post.OperationID)
+}
+
+func TestOperationOf(t *testing.T) {
+	handler := openapi.Op().ID("handler").BuildHandler()(func(rw http.ResponseWriter, req *http.Request) {})
+
+	op, src := openapi.OperationOf(nil, handler, openapi.Op().Tag("test").Build())
+	assert.Equal(t, openapi.SourceMiddleware|openapi.SourceHandler, src)
+	assert.Equal(t, "handler", op.ID())
+	assert.Equal(t, []string{"test"}, op.Tags())
+
+	_, src = openapi.OperationOf(nil, http.NotFoundHandler())
+	assert.Zero(t, src)
+}